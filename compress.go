@@ -0,0 +1,226 @@
+package render
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// AcceptEncoding header constant.
+	AcceptEncoding = "Accept-Encoding"
+	// ContentEncoding header constant.
+	ContentEncoding = "Content-Encoding"
+	// Minimum body size worth compressing.
+	defaultCompressionMinSize = 1024
+)
+
+// Compressor applies a content coding, such as gzip or br, to response bodies.
+type Compressor struct {
+	// Encoding is the content coding token matched against the Accept-Encoding header.
+	Encoding string
+	// NewWriter returns a writer that compresses everything written to it into w.
+	NewWriter func(w io.Writer) io.WriteCloser
+}
+
+// GzipCompressor returns a Compressor for the gzip content coding. An invalid level
+// falls back to gzip.DefaultCompression.
+func GzipCompressor(level int) Compressor {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		level = gzip.DefaultCompression
+	}
+
+	pool := &sync.Pool{}
+
+	return Compressor{
+		Encoding: "gzip",
+		NewWriter: func(w io.Writer) io.WriteCloser {
+			gz, ok := pool.Get().(*gzip.Writer)
+			if ok {
+				gz.Reset(w)
+			} else {
+				gz, _ = gzip.NewWriterLevel(w, level)
+			}
+
+			return pooledWriter{gz, func() { pool.Put(gz) }}
+		},
+	}
+}
+
+// DeflateCompressor returns a Compressor for the deflate content coding, which HTTP
+// defines as the zlib format. An invalid level falls back to zlib.DefaultCompression.
+func DeflateCompressor(level int) Compressor {
+	if _, err := zlib.NewWriterLevel(io.Discard, level); err != nil {
+		level = zlib.DefaultCompression
+	}
+
+	pool := &sync.Pool{}
+
+	return Compressor{
+		Encoding: "deflate",
+		NewWriter: func(w io.Writer) io.WriteCloser {
+			zw, ok := pool.Get().(*zlib.Writer)
+			if ok {
+				zw.Reset(w)
+			} else {
+				zw, _ = zlib.NewWriterLevel(w, level)
+			}
+
+			return pooledWriter{zw, func() { pool.Put(zw) }}
+		},
+	}
+}
+
+// BrotliCompressor returns a Compressor for the br content coding. An invalid level
+// falls back to brotli.DefaultCompression.
+func BrotliCompressor(level int) Compressor {
+	if level < brotli.BestSpeed || level > brotli.BestCompression {
+		level = brotli.DefaultCompression
+	}
+
+	pool := &sync.Pool{}
+
+	return Compressor{
+		Encoding: "br",
+		NewWriter: func(w io.Writer) io.WriteCloser {
+			bw, ok := pool.Get().(*brotli.Writer)
+			if ok {
+				bw.Reset(w)
+			} else {
+				bw = brotli.NewWriterLevel(w, level)
+			}
+
+			return pooledWriter{bw, func() { pool.Put(bw) }}
+		},
+	}
+}
+
+// ZstdCompressor returns a Compressor for the zstd content coding. An invalid level
+// falls back to zstd.SpeedDefault.
+func ZstdCompressor(level zstd.EncoderLevel) Compressor {
+	if level < zstd.SpeedFastest || level > zstd.SpeedBestCompression {
+		level = zstd.SpeedDefault
+	}
+
+	pool := &sync.Pool{}
+
+	return Compressor{
+		Encoding: "zstd",
+		NewWriter: func(w io.Writer) io.WriteCloser {
+			zw, ok := pool.Get().(*zstd.Encoder)
+			if ok {
+				zw.Reset(w)
+			} else {
+				// The options are valid, so there is no error. Each response is compressed by a
+				// single goroutine, as responses are compressed concurrently already.
+				zw, _ = zstd.NewWriter(w, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+			}
+
+			return pooledWriter{zw, func() { pool.Put(zw) }}
+		},
+	}
+}
+
+// pooledWriter returns its writer to a pool once closed.
+type pooledWriter struct {
+	io.WriteCloser
+	release func()
+}
+
+func (p pooledWriter) Close() error {
+	err := p.WriteCloser.Close()
+	p.release()
+
+	return err
+}
+
+// compressor returns the preferred compressor acceptable to the Accept-Encoding header, or nil.
+func (r *Render) compressor(header string) *Compressor {
+	codings := parseAcceptEncoding(header)
+
+	var (
+		best  *Compressor
+		bestQ float64
+	)
+
+	for i := range r.opt.Compressors {
+		c := &r.opt.Compressors[i]
+
+		q, ok := codings[strings.ToLower(c.Encoding)]
+		if !ok {
+			q = codings["*"]
+		}
+
+		if q > bestQ {
+			best, bestQ = c, q
+		}
+	}
+
+	return best
+}
+
+// parseAcceptEncoding maps each listed content coding to its quality.
+func parseAcceptEncoding(header string) map[string]float64 {
+	codings := map[string]float64{}
+
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if len(coding) == 0 {
+			continue
+		}
+
+		q := 1.0
+
+		for _, param := range params[1:] {
+			if k, v, ok := cut(strings.TrimSpace(param), "="); ok && strings.EqualFold(k, "q") {
+				var err error
+				if q, err = strconv.ParseFloat(v, 64); err != nil {
+					q = 0
+				}
+			}
+		}
+
+		codings[coding] = q
+	}
+
+	return codings
+}
+
+// cut is strings.Cut, which is not available in Go 1.17.
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
+
+// compressible reports whether a response is worth compressing.
+func compressible(status int, h http.Header) bool {
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+
+	if len(h.Get(ContentEncoding)) > 0 {
+		return false
+	}
+
+	// Most media formats are compressed already.
+	contentType := strings.ToLower(h.Get(ContentType))
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/woff", "application/zip", "application/gzip", "application/x-gzip"} {
+		if strings.HasPrefix(contentType, prefix) && !strings.HasPrefix(contentType, "image/svg") {
+			return false
+		}
+	}
+
+	return true
+}
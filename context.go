@@ -0,0 +1,122 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// contextWriter stops writing once its context is done, which aborts template execution
+// and encoders writing to it.
+type contextWriter struct {
+	ctx context.Context //nolint:containedctx
+	w   io.Writer
+}
+
+func (cw contextWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return cw.w.Write(p)
+}
+
+// withContext returns a writer that fails with the context's error once it is done.
+// Without a context, w is returned as is.
+func withContext(ctx context.Context, w io.Writer) io.Writer {
+	if ctx == nil {
+		return w
+	}
+
+	return contextWriter{ctx: ctx, w: w}
+}
+
+// contextErr returns the error of a context that is done, or nil.
+func contextErr(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+
+	return ctx.Err()
+}
+
+// isContextError reports whether rendering stopped because its context is done, in which
+// case the client is gone or out of time and no error response is written.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// requestContext returns the context of the request, or nil without a request.
+func requestContext(req *http.Request) context.Context {
+	if req == nil {
+		return nil
+	}
+
+	return req.Context()
+}
+
+// contextResponseWriter is a contextWriter for engines that do not take a context. Neither
+// the status nor the body is written once the context is done, and the context's error is
+// kept, as engines may ignore write errors.
+type contextResponseWriter struct {
+	http.ResponseWriter
+	ctx context.Context //nolint:containedctx
+	err error
+}
+
+func (cw *contextResponseWriter) WriteHeader(status int) {
+	if cw.done() {
+		return
+	}
+
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *contextResponseWriter) Write(p []byte) (int, error) {
+	if cw.done() {
+		return 0, cw.err
+	}
+
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends everything written so far to the client, if the response supports it.
+func (cw *contextResponseWriter) Flush() {
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok && !cw.done() {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the response, for http.ResponseController.
+func (cw *contextResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *contextResponseWriter) done() bool {
+	if cw.err == nil {
+		cw.err = cw.ctx.Err()
+	}
+
+	return cw.err != nil
+}
+
+// renderContext renders v with an engine that does not take a context, which is skipped
+// if the context is done already, and stops writing to the response once it is.
+func renderContext(ctx context.Context, w io.Writer, e Engine, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	hw, ok := w.(http.ResponseWriter)
+	if !ok {
+		return e.Render(withContext(ctx, w), v)
+	}
+
+	cw := &contextResponseWriter{ResponseWriter: hw, ctx: ctx}
+	if err := e.Render(cw, v); err != nil {
+		return err
+	}
+
+	return cw.err
+}
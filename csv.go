@@ -0,0 +1,296 @@
+package render
+
+import (
+	"bufio"
+	"context"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+// CSV built-in renderer, for comma and tab separated values.
+type CSV struct {
+	Head
+	// Delimiter separates the fields of a row. Defaults to a comma.
+	Delimiter rune
+	// BOM starts the output with a UTF-8 byte order mark, which Excel needs to detect the encoding.
+	BOM bool
+	// Filename sets a Content-Disposition header that has browsers save the response as the given file.
+	Filename string
+	// Context stops the output once it is done. Defaults to never stopping.
+	Context context.Context //nolint:containedctx
+}
+
+// CSVOptions is a struct for overriding some rendering Options for specific CSV and TSV calls.
+type CSVOptions struct {
+	// Delimiter separates the fields of a row. Defaults to a comma for CSV and a tab for TSV.
+	Delimiter rune
+	// BOM starts the output with a UTF-8 byte order mark, which Excel needs to detect the encoding.
+	BOM bool
+	// Filename sets a Content-Disposition header that has browsers save the response as the given file.
+	Filename string
+}
+
+// csvColumn is a field of a struct written as a column.
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// Render a table of values. The value is a channel, slice, array or Iterator of rows, where
+// each row is a slice of values or a struct. Structs are preceded by a header row, with the
+// names and order of the columns taken from their `csv` field tags. Rows are written as
+// they are read, and the status is only sent along with the first output, so an error in
+// the first rows can still be rendered.
+func (c CSV) Render(w io.Writer, v interface{}) error {
+	next, err := iterate(c.Context, v)
+	if err != nil {
+		return fmt.Errorf("csv: %w", err)
+	}
+
+	hw, isResponse := w.(http.ResponseWriter)
+	if isResponse && len(c.Filename) > 0 {
+		hw.Header().Set(ContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": c.Filename}))
+	}
+
+	sw := &streamWriter{w: w, head: c.Head}
+
+	fail := func(err error) error {
+		if ctxErr := contextErr(c.Context); ctxErr != nil {
+			return ctxErr
+		}
+
+		if sw.started {
+			return streamError{err}
+		}
+
+		if isResponse && len(c.Filename) > 0 {
+			hw.Header().Del(ContentDisposition)
+		}
+
+		return err
+	}
+
+	// The csv package reuses this writer, so the byte order mark is buffered along with the rows.
+	bw := bufio.NewWriter(withContext(c.Context, sw))
+	if c.BOM {
+		_, _ = bw.WriteString("\ufeff")
+	}
+
+	cw := csv.NewWriter(bw)
+	if c.Delimiter != 0 {
+		cw.Comma = c.Delimiter
+	}
+
+	var (
+		structType reflect.Type
+		columns    []csvColumn
+	)
+
+	writeHeader := func(t reflect.Type) error {
+		structType = t
+		columns = csvColumns(t)
+
+		header := make([]string, 0, len(columns))
+		for _, column := range columns {
+			header = append(header, column.name)
+		}
+
+		return cw.Write(header)
+	}
+
+	// The header of a slice of structs is known, and written, even if the slice is empty.
+	if t := reflect.TypeOf(v); t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		if elem := indirectType(t.Elem()); elem.Kind() == reflect.Struct {
+			if err := writeHeader(elem); err != nil {
+				return fail(err)
+			}
+		}
+	}
+
+	for {
+		item, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fail(err)
+		}
+
+		row := reflect.ValueOf(item)
+		for row.Kind() == reflect.Ptr && !row.IsNil() {
+			row = row.Elem()
+		}
+
+		var record []string
+
+		switch row.Kind() { //nolint:exhaustive
+		case reflect.Struct:
+			if structType == nil {
+				if err := writeHeader(row.Type()); err != nil {
+					return fail(err)
+				}
+			} else if row.Type() != structType {
+				return fail(fmt.Errorf("csv: row of type %s in a table of %s", row.Type(), structType))
+			}
+
+			record = make([]string, 0, len(columns))
+
+			for _, column := range columns {
+				value, err := csvValue(csvField(row, column.index))
+				if err != nil {
+					return fail(err)
+				}
+
+				record = append(record, value)
+			}
+		case reflect.Slice, reflect.Array:
+			record = make([]string, 0, row.Len())
+
+			for i := 0; i < row.Len(); i++ {
+				value, err := csvValue(row.Index(i))
+				if err != nil {
+					return fail(err)
+				}
+
+				record = append(record, value)
+			}
+		default:
+			return fail(fmt.Errorf("csv: unsupported row type %T", item))
+		}
+
+		if err := cw.Write(record); err != nil {
+			return fail(err)
+		}
+	}
+
+	cw.Flush()
+
+	if err := cw.Error(); err != nil {
+		return fail(err)
+	}
+
+	sw.writeHead()
+
+	return nil
+}
+
+// RenderRequest renders the table until the request's context is done, unless a Context is set.
+func (c CSV) RenderRequest(w io.Writer, req *http.Request, v interface{}) error {
+	if c.Context == nil {
+		c.Context = req.Context()
+	}
+
+	return c.Render(w, v)
+}
+
+// csvColumns returns the columns of a struct type: its exported fields, including those of
+// exported embedded structs, named by their `csv` tag or else by the field name. Fields
+// tagged `csv:"-"` are skipped.
+func csvColumns(t reflect.Type) []csvColumn {
+	var columns []csvColumn
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, ok := field.Tag.Lookup("csv")
+		if name == "-" {
+			continue
+		}
+
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+
+		if field.Anonymous && !ok && indirectType(field.Type).Kind() == reflect.Struct {
+			for _, column := range csvColumns(indirectType(field.Type)) {
+				column.index = append([]int{i}, column.index...)
+				columns = append(columns, column)
+			}
+
+			continue
+		}
+
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		columns = append(columns, csvColumn{name: name, index: []int{i}})
+	}
+
+	return columns
+}
+
+// csvField returns the field of the struct at the index, or an invalid value if it is
+// inside a nil embedded pointer.
+func csvField(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 {
+			for v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}
+				}
+
+				v = v.Elem()
+			}
+		}
+
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// csvValue formats a field. Nil values are empty, and values implementing
+// encoding.TextMarshaler are formatted by it.
+func csvValue(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+
+			return string(text), err
+		}
+
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return "", nil
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+
+		return string(text), err
+	}
+
+	return fmt.Sprint(v.Interface()), nil
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// CSV writes the rows of v as comma separated values. See the CSV engine for the values
+// that can be rendered.
+func (r *Render) CSV(w io.Writer, status int, v interface{}, csvOpt ...CSVOptions) error {
+	return r.For(nil).CSV(w, status, v, csvOpt...)
+}
+
+// TSV writes the rows of v as tab separated values. See the CSV engine for the values
+// that can be rendered.
+func (r *Render) TSV(w io.Writer, status int, v interface{}, csvOpt ...CSVOptions) error {
+	return r.For(nil).TSV(w, status, v, csvOpt...)
+}
@@ -1,5 +1,5 @@
 /*
-Package render is a package that provides functionality for easily rendering JSON, XML, binary data, and HTML templates.
+Package render is a package that provides functionality for easily rendering JSON, XML, YAML, binary data, and HTML templates.
 
 	package main
 
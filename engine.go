@@ -2,11 +2,20 @@ package render
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"html/template"
 	"io"
 	"net/http"
+	"reflect"
+	texttemplate "text/template"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
 )
 
 // Engine is the generic interface for all responses.
@@ -20,6 +29,11 @@ type Head struct {
 	Status      int
 }
 
+// CBOR built-in renderer.
+type CBOR struct {
+	Head
+}
+
 // Data built-in renderer.
 type Data struct {
 	Head
@@ -30,8 +44,13 @@ type HTML struct {
 	Head
 	Name      string
 	Templates *template.Template
+	// Context stops the template execution once it is done. Defaults to never stopping.
+	Context context.Context //nolint:containedctx
+	// StreamingHTML writes the response while the template executes instead of buffering it.
+	StreamingHTML bool
 
-	bp GenericBufferPool
+	bp    GenericBufferPool
+	flush *flushPoint
 }
 
 // JSON built-in renderer.
@@ -41,6 +60,8 @@ type JSON struct {
 	UnEscapeHTML  bool
 	Prefix        []byte
 	StreamingJSON bool
+	// Context stops the encoding once it is done. Defaults to never stopping.
+	Context context.Context //nolint:containedctx
 }
 
 // JSONP built-in renderer.
@@ -50,11 +71,38 @@ type JSONP struct {
 	Callback string
 }
 
+// MsgPack built-in renderer.
+type MsgPack struct {
+	Head
+}
+
+// NDJSON built-in renderer.
+type NDJSON struct {
+	Head
+	UnEscapeHTML bool
+	// Context stops the stream once it is done. Defaults to never stopping.
+	Context context.Context //nolint:containedctx
+}
+
+// Iterator returns the next item to render, or io.EOF once there are no more items.
+type Iterator func() (interface{}, error)
+
 // Text built-in renderer.
 type Text struct {
 	Head
 }
 
+// TextTemplate built-in renderer.
+type TextTemplate struct {
+	Head
+	Name      string
+	Templates *texttemplate.Template
+	// Context stops the template execution once it is done. Defaults to never stopping.
+	Context context.Context //nolint:containedctx
+
+	bp GenericBufferPool
+}
+
 // XML built-in renderer.
 type XML struct {
 	Head
@@ -62,12 +110,36 @@ type XML struct {
 	Prefix []byte
 }
 
+// YAML built-in renderer.
+type YAML struct {
+	Head
+	Indent bool
+	Prefix []byte
+}
+
 // Write outputs the header content.
 func (h Head) Write(w http.ResponseWriter) {
 	w.Header().Set(ContentType, h.ContentType)
 	w.WriteHeader(h.Status)
 }
 
+// Render a CBOR response. Struct fields are named by their `cbor` tags, or else their `json` tags.
+func (c CBOR) Render(w io.Writer, v interface{}) error {
+	result, err := cbor.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// CBOR marshaled fine, write out the result.
+	if hw, ok := w.(http.ResponseWriter); ok {
+		c.Head.Write(hw)
+	}
+
+	_, _ = w.Write(result)
+
+	return nil
+}
+
 // Render a data response.
 func (d Data) Render(w io.Writer, v interface{}) error {
 	if hw, ok := w.(http.ResponseWriter); ok {
@@ -86,6 +158,28 @@ func (d Data) Render(w io.Writer, v interface{}) error {
 
 // Render a HTML response.
 func (h HTML) Render(w io.Writer, binding interface{}) error {
+	if h.StreamingHTML {
+		return h.renderStreamingHTML(w, binding)
+	}
+
+	// The response is already held in memory, so there is no need to buffer it twice.
+	if br, ok := w.(*bufferedResponse); ok {
+		err := h.Templates.ExecuteTemplate(withContext(h.Context, br.buf), h.Name, binding)
+		if ctxErr := contextErr(h.Context); ctxErr != nil {
+			err = ctxErr
+		}
+
+		if err != nil {
+			br.buf.Reset()
+
+			return err
+		}
+
+		h.Head.Write(br)
+
+		return nil
+	}
+
 	var buf *bytes.Buffer
 	if h.bp != nil {
 		// If we have a bufferpool, allocate from it
@@ -93,7 +187,12 @@ func (h HTML) Render(w io.Writer, binding interface{}) error {
 		defer h.bp.Put(buf)
 	}
 
-	err := h.Templates.ExecuteTemplate(buf, h.Name, binding)
+	err := h.Templates.ExecuteTemplate(withContext(h.Context, buf), h.Name, binding)
+	if ctxErr := contextErr(h.Context); ctxErr != nil {
+		// Errors of nested templates wrap the context's error, which is returned as is.
+		return ctxErr
+	}
+
 	if err != nil {
 		return err
 	}
@@ -107,6 +206,42 @@ func (h HTML) Render(w io.Writer, binding interface{}) error {
 	return nil
 }
 
+// renderStreamingHTML writes the output of the template as it executes, flushing it to the
+// client whenever a layout yields and once the template is done. Errors after the first
+// output can not change the status anymore, and cut the response short.
+func (h HTML) renderStreamingHTML(w io.Writer, binding interface{}) error {
+	sw := &streamWriter{w: w, head: h.Head}
+	if h.flush != nil {
+		h.flush.sw = sw
+	}
+
+	err := h.Templates.ExecuteTemplate(withContext(h.Context, sw), h.Name, binding)
+	if ctxErr := contextErr(h.Context); ctxErr != nil {
+		err = ctxErr
+	}
+
+	if err != nil {
+		if sw.started {
+			return streamError{err}
+		}
+
+		return err
+	}
+
+	sw.Flush()
+
+	return nil
+}
+
+// RenderRequest renders the template until the request's context is done, unless a Context is set.
+func (h HTML) RenderRequest(w io.Writer, req *http.Request, binding interface{}) error {
+	if h.Context == nil {
+		h.Context = req.Context()
+	}
+
+	return h.Render(w, binding)
+}
+
 // Render a JSON response.
 func (j JSON) Render(w io.Writer, v interface{}) error {
 	if j.StreamingJSON {
@@ -125,6 +260,10 @@ func (j JSON) Render(w io.Writer, v interface{}) error {
 		return err
 	}
 
+	if err := contextErr(j.Context); err != nil {
+		return err
+	}
+
 	output := buf.Bytes()
 
 	// JSON marshaled fine, write out the result.
@@ -147,6 +286,10 @@ func (j JSON) Render(w io.Writer, v interface{}) error {
 }
 
 func (j JSON) renderStreamingJSON(w io.Writer, v interface{}) error {
+	if err := contextErr(j.Context); err != nil {
+		return err
+	}
+
 	if hw, ok := w.(http.ResponseWriter); ok {
 		j.Head.Write(hw)
 	}
@@ -155,7 +298,7 @@ func (j JSON) renderStreamingJSON(w io.Writer, v interface{}) error {
 		_, _ = w.Write(j.Prefix)
 	}
 
-	encoder := json.NewEncoder(w)
+	encoder := json.NewEncoder(withContext(j.Context, w))
 	encoder.SetEscapeHTML(!j.UnEscapeHTML)
 
 	if j.Indent {
@@ -165,6 +308,15 @@ func (j JSON) renderStreamingJSON(w io.Writer, v interface{}) error {
 	return encoder.Encode(v)
 }
 
+// RenderRequest encodes the value until the request's context is done, unless a Context is set.
+func (j JSON) RenderRequest(w io.Writer, req *http.Request, v interface{}) error {
+	if j.Context == nil {
+		j.Context = req.Context()
+	}
+
+	return j.Render(w, v)
+}
+
 // Render a JSONP response.
 func (j JSONP) Render(w io.Writer, v interface{}) error {
 	var result []byte
@@ -198,6 +350,157 @@ func (j JSONP) Render(w io.Writer, v interface{}) error {
 	return nil
 }
 
+// Render a MessagePack response. Struct fields are named by their `msgpack` tags, or else their `json` tags.
+func (m MsgPack) Render(w io.Writer, v interface{}) error {
+	var buf bytes.Buffer
+
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+
+	// MessagePack marshaled fine, write out the result.
+	if hw, ok := w.(http.ResponseWriter); ok {
+		m.Head.Write(hw)
+	}
+
+	_, _ = buf.WriteTo(w)
+
+	return nil
+}
+
+// Render a newline delimited JSON response. The value can be a channel, slice, array or
+// Iterator, and each item is flushed to the client as soon as it has been written.
+func (n NDJSON) Render(w io.Writer, v interface{}) error {
+	next, err := iterate(n.Context, v)
+	if err != nil {
+		return fmt.Errorf("ndjson: %w", err)
+	}
+
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(!n.UnEscapeHTML)
+
+	// Headers are delayed until the first item encodes so an early failure can still be reported.
+	wroteHead := false
+	writeHead := func() {
+		if hw, ok := w.(http.ResponseWriter); ok && !wroteHead {
+			n.Head.Write(hw)
+		}
+
+		wroteHead = true
+	}
+
+	// Once lines were sent, the status can not change anymore.
+	fail := func(err error) error {
+		if wroteHead {
+			return streamError{err}
+		}
+
+		return err
+	}
+
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		if err := contextErr(n.Context); err != nil {
+			return err
+		}
+
+		item, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fail(err)
+		}
+
+		buf.Reset()
+
+		if err := encoder.Encode(item); err != nil {
+			return fail(err)
+		}
+
+		writeHead()
+
+		if _, err := buf.WriteTo(w); err != nil {
+			return fail(err)
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	writeHead()
+
+	return nil
+}
+
+// RenderRequest renders the stream until the request's context is done, unless a Context is set.
+func (n NDJSON) RenderRequest(w io.Writer, req *http.Request, v interface{}) error {
+	if n.Context == nil {
+		n.Context = req.Context()
+	}
+
+	return n.Render(w, v)
+}
+
+// iterate returns an Iterator over the items of a channel, slice, array or Iterator, which
+// the streaming engines render one at a time. Receiving from a channel stops once the
+// context is done.
+func iterate(ctx context.Context, v interface{}) (Iterator, error) {
+	switch it := v.(type) {
+	case Iterator:
+		return it, nil
+	case func() (interface{}, error):
+		return it, nil
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() { //nolint:exhaustive
+	case reflect.Chan:
+		var done <-chan struct{}
+		if ctx != nil {
+			done = ctx.Done()
+		}
+
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: rv},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)},
+		}
+
+		return func() (interface{}, error) {
+			chosen, item, ok := reflect.Select(cases)
+			if chosen == 1 {
+				return nil, ctx.Err()
+			}
+
+			if !ok {
+				return nil, io.EOF
+			}
+
+			return item.Interface(), nil
+		}, nil
+	case reflect.Slice, reflect.Array:
+		i := 0
+
+		return func() (interface{}, error) {
+			if i >= rv.Len() {
+				return nil, io.EOF
+			}
+			i++
+
+			return rv.Index(i - 1).Interface(), nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %T", v)
+}
+
 // Render a text response.
 func (t Text) Render(w io.Writer, v interface{}) error {
 	if hw, ok := w.(http.ResponseWriter); ok {
@@ -214,6 +517,70 @@ func (t Text) Render(w io.Writer, v interface{}) error {
 	return nil
 }
 
+// Render a text template response.
+func (t TextTemplate) Render(w io.Writer, binding interface{}) error {
+	// The response is already held in memory, so there is no need to buffer it twice.
+	if br, ok := w.(*bufferedResponse); ok {
+		err := t.Templates.ExecuteTemplate(withContext(t.Context, br.buf), t.Name, binding)
+		if ctxErr := contextErr(t.Context); ctxErr != nil {
+			err = ctxErr
+		}
+
+		if err != nil {
+			br.buf.Reset()
+
+			return err
+		}
+
+		t.writeHead(br)
+
+		return nil
+	}
+
+	var buf *bytes.Buffer
+	if t.bp != nil {
+		// If we have a bufferpool, allocate from it
+		buf = t.bp.Get()
+		defer t.bp.Put(buf)
+	}
+
+	err := t.Templates.ExecuteTemplate(withContext(t.Context, buf), t.Name, binding)
+	if ctxErr := contextErr(t.Context); ctxErr != nil {
+		// Errors of nested templates wrap the context's error, which is returned as is.
+		return ctxErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if hw, ok := w.(http.ResponseWriter); ok {
+		t.writeHead(hw)
+	}
+
+	_, _ = buf.WriteTo(w)
+
+	return nil
+}
+
+// RenderRequest renders the template until the request's context is done, unless a Context is set.
+func (t TextTemplate) RenderRequest(w io.Writer, req *http.Request, binding interface{}) error {
+	if t.Context == nil {
+		t.Context = req.Context()
+	}
+
+	return t.Render(w, binding)
+}
+
+// writeHead writes the header, keeping a content type that was set already.
+func (t TextTemplate) writeHead(w http.ResponseWriter) {
+	if c := w.Header().Get(ContentType); c != "" {
+		t.Head.ContentType = c
+	}
+
+	t.Head.Write(w)
+}
+
 // Render an XML response.
 func (x XML) Render(w io.Writer, v interface{}) error {
 	var result []byte
@@ -244,3 +611,65 @@ func (x XML) Render(w io.Writer, v interface{}) error {
 
 	return nil
 }
+
+// Render a YAML response.
+func (y YAML) Render(w io.Writer, v interface{}) (err error) {
+	// The yaml package panics on values it cannot represent, such as channels and funcs.
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("yaml: %v", rec)
+		}
+	}()
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+
+	if y.Indent {
+		encoder.SetIndent(2)
+
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+	} else {
+		// Without indenting, the value is written on a single line in flow style.
+		var node yaml.Node
+		if err := node.Encode(v); err != nil {
+			return err
+		}
+
+		flowStyle(&node)
+
+		if err := encoder.Encode(&node); err != nil {
+			return err
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	// YAML marshaled fine, write out the result.
+	if hw, ok := w.(http.ResponseWriter); ok {
+		y.Head.Write(hw)
+	}
+
+	if len(y.Prefix) > 0 {
+		_, _ = w.Write(y.Prefix)
+	}
+
+	_, _ = buf.WriteTo(w)
+
+	return nil
+}
+
+// flowStyle sets the flow style on the mappings and sequences of the node.
+func flowStyle(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style |= yaml.FlowStyle
+	}
+
+	for _, child := range node.Content {
+		flowStyle(child)
+	}
+}
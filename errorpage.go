@@ -0,0 +1,106 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Number of source lines shown around the line of a compile error.
+const compileErrorContext = 5
+
+var compileErrorTemplate = template.Must(template.New("compile-error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Template compile error</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+pre { background: #f6f6f6; padding: 1em; overflow: auto; }
+.current { background: #fdd; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Template compile error</h1>
+<p><code>{{ .Path }}{{ if .Line }}:{{ .Line }}{{ end }}</code></p>
+<pre>{{ .Message }}</pre>
+{{ if .Source }}<pre>{{ range .Source }}<span{{ if .Current }} class="current"{{ end }}>{{ printf "%4d" .Number }}  {{ .Text }}</span>
+{{ end }}</pre>{{ end }}
+<p>This page is shown until the templates compile again.</p>
+</body>
+</html>
+`))
+
+// sourceLine is a line of template source shown on the compile error page.
+type sourceLine struct {
+	Number  int
+	Text    string
+	Current bool
+}
+
+// compileErrorPage renders a *CompileError as an HTML page for use during development.
+type compileErrorPage struct {
+	Head
+}
+
+// Render the compile error page.
+func (p compileErrorPage) Render(w io.Writer, v interface{}) error {
+	compileErr, ok := v.(*CompileError)
+	if !ok {
+		return fmt.Errorf("render: compile error page expects a *CompileError, got %T", v)
+	}
+
+	data := struct {
+		Path    string
+		Line    int
+		Message string
+		Source  []sourceLine
+	}{
+		Path:    compileErr.Path,
+		Line:    compileErr.Line,
+		Message: compileErr.Err.Error(),
+		Source:  sourceContext(compileErr.Source, compileErr.Line),
+	}
+
+	var buf bytes.Buffer
+	if err := compileErrorTemplate.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	if hw, ok := w.(http.ResponseWriter); ok {
+		p.Head.Write(hw)
+	}
+
+	_, err := buf.WriteTo(w)
+
+	return err
+}
+
+// sourceContext returns the lines of source around the given line.
+func sourceContext(source []byte, line int) []sourceLine {
+	if line <= 0 || len(source) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(string(source), "\n")
+
+	first := line - compileErrorContext
+	if first < 1 {
+		first = 1
+	}
+
+	last := line + compileErrorContext
+	if last > len(lines) {
+		last = len(lines)
+	}
+
+	context := make([]sourceLine, 0, last-first+1)
+	for n := first; n <= last; n++ {
+		context = append(context, sourceLine{Number: n, Text: lines[n-1], Current: n == line})
+	}
+
+	return context
+}
@@ -0,0 +1,46 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// ETag header constant.
+	ETag = "ETag"
+	// IfNoneMatch header constant.
+	IfNoneMatch = "If-None-Match"
+)
+
+// computeETag returns a quoted entity tag for the body. Strong tags are specific to the
+// content coding, since the encoded bytes differ, while weak tags are shared between them.
+func computeETag(body []byte, weak bool, encoding string) string {
+	sum := sha256.Sum256(body)
+	tag := hex.EncodeToString(sum[:16])
+
+	if weak {
+		return `W/"` + tag + `"`
+	}
+
+	if len(encoding) > 0 {
+		tag += "-" + encoding
+	}
+
+	return `"` + tag + `"`
+}
+
+// etagMatches reports whether the If-None-Match header matches the entity tag, using
+// the weak comparison If-None-Match calls for.
+func etagMatches(header string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,133 @@
+package render
+
+import (
+	"io"
+	"mime"
+	"sort"
+)
+
+// EngineFactory returns the Engine used to render a single response with the given Head.
+type EngineFactory func(head Head) Engine
+
+// Format describes an Engine registered under a media type.
+type Format struct {
+	// ContentType sent with the response. Defaults to the media type the format is registered under.
+	ContentType string
+	// If DisableCharset is set to true, the Charset option is not appended to the ContentType. Default is false.
+	DisableCharset bool
+	// Engine builds the Engine for each response. Any format specific options should be captured here.
+	Engine EngineFactory
+}
+
+// Register adds or replaces the format rendered for the media type. Registered formats
+// can be rendered directly with Format and are offered by Negotiate for every value,
+// in the order they were first registered.
+func (r *Render) Register(mediaType string, format Format) {
+	mediaType = normalizeMediaType(mediaType)
+	if len(format.ContentType) == 0 {
+		format.ContentType = mediaType
+	}
+
+	r.formatLock.Lock()
+	defer r.formatLock.Unlock()
+
+	if r.formats == nil {
+		r.formats = map[string]Format{}
+	}
+
+	if _, ok := r.formats[mediaType]; !ok {
+		r.formatOrder = append(r.formatOrder, mediaType)
+	}
+
+	r.formats[mediaType] = format
+}
+
+// Format renders v with the format registered for the media type.
+func (r *Render) Format(w io.Writer, mediaType string, status int, v interface{}) error {
+	return r.For(nil).Format(w, mediaType, status, v)
+}
+
+func (r *Render) registerFormats() {
+	r.Register(r.opt.JSONContentType, Format{Engine: r.newJSON})
+	r.Register(r.opt.XMLContentType, Format{Engine: r.newXML})
+
+	// XML is commonly requested under both of its registered media types.
+	for _, alias := range []string{ContentXML, "application/xml"} {
+		r.Register(alias, Format{ContentType: r.opt.XMLContentType, Engine: r.newXML})
+	}
+
+	r.Register(r.opt.YAMLContentType, Format{Engine: r.newYAML})
+
+	// Many clients still request YAML under its older, unregistered media types.
+	for _, alias := range []string{ContentYAML, "application/x-yaml", "text/yaml"} {
+		r.Register(alias, Format{ContentType: r.opt.YAMLContentType, Engine: r.newYAML})
+	}
+
+	// Binary formats have no character encoding.
+	r.Register(r.opt.MsgPackContentType, Format{DisableCharset: true, Engine: r.newMsgPack})
+
+	for _, alias := range []string{ContentMsgPack, "application/vnd.msgpack", "application/x-msgpack"} {
+		r.Register(alias, Format{ContentType: r.opt.MsgPackContentType, DisableCharset: true, Engine: r.newMsgPack})
+	}
+
+	r.Register(r.opt.CBORContentType, Format{DisableCharset: true, Engine: r.newCBOR})
+
+	mediaTypes := make([]string, 0, len(r.opt.Formats))
+	for mediaType := range r.opt.Formats {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+
+	sort.Strings(mediaTypes)
+
+	for _, mediaType := range mediaTypes {
+		r.Register(mediaType, r.opt.Formats[mediaType])
+	}
+}
+
+func (r *Render) lookupFormat(mediaType string) (Format, bool) {
+	r.formatLock.RLock()
+	defer r.formatLock.RUnlock()
+
+	format, ok := r.formats[normalizeMediaType(mediaType)]
+
+	return format, ok
+}
+
+func (r *Render) formatEngine(format Format, status int) Engine { //nolint:ireturn
+	head := Head{
+		ContentType: format.ContentType,
+		Status:      status,
+	}
+
+	if !format.DisableCharset {
+		head.ContentType += r.compiledCharset
+	}
+
+	return format.Engine(head)
+}
+
+// formatOffers returns an offer for every registered format, in registration order.
+func (rr *RequestRender) formatOffers(v interface{}) []offer {
+	rr.r.formatLock.RLock()
+	defer rr.r.formatLock.RUnlock()
+
+	offers := make([]offer, 0, len(rr.r.formatOrder))
+
+	for _, mediaType := range rr.r.formatOrder {
+		mediaType := mediaType
+		offers = append(offers, offer{mediaType, func(w io.Writer, status int) error {
+			return rr.Format(w, mediaType, status, v)
+		}})
+	}
+
+	return offers
+}
+
+// normalizeMediaType lowercases the media type and strips any parameters from it.
+func normalizeMediaType(mediaType string) string {
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		return parsed
+	}
+
+	return mediaType
+}
@@ -3,12 +3,13 @@ package render
 import (
 	"fmt"
 	"html/template"
+	"net/http"
 )
 
 // Included helper functions for use when rendering HTML.
 func helperFuncs() template.FuncMap {
 	return template.FuncMap{
-		"yield": func() (string, error) {
+		"yield": func(...interface{}) (string, error) {
 			return "", fmt.Errorf("yield called with no layout defined")
 		},
 		"partial": func() (string, error) {
@@ -17,5 +18,14 @@ func helperFuncs() template.FuncMap {
 		"current": func() (string, error) {
 			return "", nil
 		},
+		"extends": func(string) string {
+			return ""
+		},
+		"request": func() *http.Request {
+			return nil
+		},
+		"flush": func() string {
+			return ""
+		},
 	}
 }
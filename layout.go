@@ -0,0 +1,177 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"text/template/parse"
+)
+
+// executor is the part of html/template and text/template sets used by layouts.
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// layout implements the layout functions for the template being rendered, independently
+// of the template package.
+type layout struct {
+	templates executor
+	defined   func(name string) bool
+	name      string
+	binding   interface{}
+	ctx       context.Context //nolint:containedctx
+	flush     *flushPoint
+
+	// chain lists the layouts from the outermost one in, followed by the template itself.
+	// Each yield renders the next entry of the chain.
+	chain []string
+	depth *int
+
+	withoutSuffix bool
+}
+
+// newLayout returns the layout for rendering the named template inside the given layout.
+func (r *Render) newLayout(templates executor, defined func(name string) bool, parents map[string]string, layoutName, name string, binding interface{}) (layout, error) {
+	chain, err := layoutChain(defined, parents, layoutName)
+	if err != nil {
+		return layout{}, err
+	}
+
+	return layout{
+		templates:     templates,
+		defined:       defined,
+		name:          name,
+		binding:       binding,
+		chain:         append(chain, name),
+		depth:         new(int),
+		withoutSuffix: r.opt.RenderPartialsWithoutPrefix,
+	}, nil
+}
+
+// layoutChain resolves the layouts wrapping a template, starting from the given layout and
+// following the layouts each one extends. The outermost layout comes first.
+func layoutChain(defined func(name string) bool, parents map[string]string, layoutName string) ([]string, error) {
+	chain := []string{layoutName}
+
+	for child := layoutName; ; {
+		parent, ok := parents[child]
+		if !ok {
+			return chain, nil
+		}
+
+		if !defined(parent) {
+			return nil, fmt.Errorf("render: layout %q extends undefined layout %q", child, parent)
+		}
+
+		for _, l := range chain {
+			if l == parent {
+				return nil, fmt.Errorf("render: layout %q extends %q, which already wraps it", child, parent)
+			}
+		}
+
+		chain = append([]string{parent}, chain...)
+		child = parent
+	}
+}
+
+// outermost returns the name of the layout to execute.
+func (l layout) outermost() string {
+	return l.chain[0]
+}
+
+func (l layout) execute(name string) (string, error) {
+	var buf bytes.Buffer
+	err := l.templates.ExecuteTemplate(withContext(l.ctx, &buf), name, l.binding)
+
+	return buf.String(), err
+}
+
+// yield renders the next layout in, or the template once all layouts are rendered.
+func (l layout) yield() (string, error) {
+	*l.depth++
+	defer func() { *l.depth-- }()
+
+	if *l.depth >= len(l.chain) {
+		return "", fmt.Errorf("render: yield called by %q, which is not a layout", l.name)
+	}
+
+	// Send what the layout rendered so far, such as its head, while the rest renders.
+	l.flush.flush()
+
+	// A streaming response is written to in order, so the next entry can write to it
+	// directly rather than be held in memory until it is done.
+	if w := l.flush.writer(); w != nil {
+		return "", l.templates.ExecuteTemplate(withContext(l.ctx, w), l.chain[*l.depth], l.binding)
+	}
+
+	return l.execute(l.chain[*l.depth])
+}
+
+// yieldSection renders the named section of the template, which is the "name-current"
+// template, or else the "name" template. If neither is defined, the optional fallback
+// is returned, formatted by format.
+func (l layout) yieldSection(args []interface{}, format func(fallback interface{}) string) (string, error) {
+	name, ok := args[0].(string)
+	if !ok || len(args) > 2 {
+		return "", fmt.Errorf("render: yield expects a section name and an optional fallback, got %v", args)
+	}
+
+	for _, section := range []string{fmt.Sprintf("%s-%s", name, l.name), name} {
+		if l.defined(section) {
+			return l.execute(section)
+		}
+	}
+
+	if len(args) == 2 {
+		return format(args[1]), nil
+	}
+
+	return "", nil
+}
+
+// current returns the name of the template inside the layouts.
+func (l layout) current() (string, error) {
+	return l.name, nil
+}
+
+// partial renders the "partialName-name" template, which must be defined if required.
+func (l layout) partial(partialName string, required bool) (string, error) {
+	fullPartialName := fmt.Sprintf("%s-%s", partialName, l.name)
+	if !l.defined(fullPartialName) && l.withoutSuffix {
+		fullPartialName = partialName
+	}
+
+	if required || l.defined(fullPartialName) {
+		return l.execute(fullPartialName)
+	}
+
+	return "", nil
+}
+
+// extends returns the layout named by a top level {{ extends "name" }} action of the tree.
+func extends(tree *parse.Tree) string {
+	if tree == nil || tree.Root == nil {
+		return ""
+	}
+
+	for _, node := range tree.Root.Nodes {
+		action, ok := node.(*parse.ActionNode)
+		if !ok || action.Pipe == nil || len(action.Pipe.Cmds) == 0 {
+			continue
+		}
+
+		args := action.Pipe.Cmds[0].Args
+		if len(args) != 2 {
+			continue
+		}
+
+		if ident, ok := args[0].(*parse.IdentifierNode); ok && ident.Ident == "extends" {
+			if parent, ok := args[1].(*parse.StringNode); ok {
+				return parent.Text
+			}
+		}
+	}
+
+	return ""
+}
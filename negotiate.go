@@ -0,0 +1,152 @@
+package render
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Accept header constant.
+const Accept = "Accept"
+
+// HTMLTemplate pairs a template name with its binding so Negotiate can offer an
+// HTML representation. When any other representation is chosen, Binding is rendered instead.
+type HTMLTemplate struct {
+	Name        string
+	Binding     interface{}
+	HTMLOptions HTMLOptions
+}
+
+// offer is a single representation Negotiate can choose from.
+type offer struct {
+	mediaType string
+	render    func(w io.Writer, status int) error
+}
+
+// acceptRange is a single media range parsed from an Accept header.
+type acceptRange struct {
+	mediaType string
+	params    map[string]string
+	q         float64
+}
+
+// Negotiate renders v with the engine that best matches the request's Accept header.
+// Strings are offered as Text, byte slices as Data, HTMLTemplate values as HTML, and
+// everything else may be rendered by any registered format, such as JSON or XML. The
+// Vary header is always updated, and a 406 Not Acceptable response is written when none
+// of the offered types are acceptable. The response is rendered as if through For(req).
+func (r *Render) Negotiate(w http.ResponseWriter, req *http.Request, status int, v interface{}) error {
+	return r.For(req).Negotiate(w, status, v)
+}
+
+// negotiate returns the index of the offer that best satisfies the Accept header,
+// or -1 if none of them are acceptable. Ties are won by the earliest offer.
+func negotiate(header string, offers []string) int {
+	ranges := parseAccept(header)
+	if len(ranges) == 0 {
+		if len(offers) == 0 {
+			return -1
+		}
+
+		return 0
+	}
+
+	best, bestQ := -1, 0.0
+
+	for i, o := range offers {
+		if q := acceptQuality(ranges, o); q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+
+	return best
+}
+
+// parseAccept parses the media ranges of an Accept header, skipping any that are malformed.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+
+	for _, part := range strings.Split(header, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil || strings.Count(mediaType, "/") != 1 {
+			continue
+		}
+
+		ar := acceptRange{mediaType: mediaType, params: params, q: 1}
+
+		if q, ok := params["q"]; ok {
+			delete(params, "q")
+
+			ar.q, err = strconv.ParseFloat(q, 64)
+			if err != nil || ar.q < 0 || ar.q > 1 {
+				continue
+			}
+		}
+
+		// The charset is always chosen by Render, so it never excludes a match.
+		delete(params, "charset")
+
+		ranges = append(ranges, ar)
+	}
+
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range matching the media type.
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	mediaType, params, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return 0
+	}
+
+	q, specificity := 0.0, -1
+
+	for _, ar := range ranges {
+		s := ar.specificity(mediaType, params)
+		if s > specificity {
+			q, specificity = ar.q, s
+		}
+	}
+
+	return q
+}
+
+// specificity ranks how closely the range matches the media type, returning -1 if it does not.
+func (ar acceptRange) specificity(mediaType string, params map[string]string) int {
+	for k, v := range ar.params {
+		if params[k] != v {
+			return -1
+		}
+	}
+
+	// Parameters only break ties between ranges of the same kind.
+	switch {
+	case ar.mediaType == "*/*":
+		return len(ar.params)
+	case strings.HasSuffix(ar.mediaType, "/*") && strings.HasPrefix(mediaType, ar.mediaType[:len(ar.mediaType)-1]):
+		return 100 + len(ar.params)
+	case ar.mediaType == mediaType:
+		return 200 + len(ar.params)
+	}
+
+	return -1
+}
+
+// addVary appends the field to the Vary header unless it is already listed.
+func addVary(h http.Header, field string) {
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f == "*" || strings.EqualFold(f, field) {
+				return
+			}
+		}
+	}
+
+	h.Add("Vary", field)
+}
@@ -0,0 +1,164 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+const (
+	// ContentProblemJSON header value for JSON problem details.
+	ContentProblemJSON = "application/problem+json"
+	// ContentProblemXML header value for XML problem details.
+	ContentProblemXML = "application/problem+xml"
+	// Namespace of XML problem details documents.
+	problemNamespace = "urn:ietf:rfc:7807"
+)
+
+// Problem is an RFC 9457 problem details document. Empty members are omitted.
+type Problem struct {
+	// Type is a URI reference identifying the problem type. Clients assume "about:blank" when omitted.
+	Type string
+	// Title is a short, human-readable summary of the problem type.
+	Title string
+	// Status is the HTTP status code. Defaults to the status the problem is rendered with.
+	Status int
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string
+	// Instance is a URI reference identifying this occurrence of the problem.
+	Instance string
+	// Extensions are additional members rendered alongside the standard ones.
+	Extensions map[string]interface{}
+}
+
+// members returns the problem's members in a stable order, standard members first.
+func (p Problem) members() ([]string, map[string]interface{}) {
+	keys := []string{}
+	values := map[string]interface{}{}
+
+	add := func(key string, value interface{}, present bool) {
+		if present {
+			keys = append(keys, key)
+			values[key] = value
+		}
+	}
+
+	add("type", p.Type, len(p.Type) > 0)
+	add("title", p.Title, len(p.Title) > 0)
+	add("status", p.Status, p.Status != 0)
+	add("detail", p.Detail, len(p.Detail) > 0)
+	add("instance", p.Instance, len(p.Instance) > 0)
+
+	extensions := make([]string, 0, len(p.Extensions))
+
+	for k := range p.Extensions {
+		// Extensions can not replace the standard members.
+		switch k {
+		case "type", "title", "status", "detail", "instance":
+			continue
+		}
+
+		extensions = append(extensions, k)
+	}
+
+	sort.Strings(extensions)
+
+	for _, k := range extensions {
+		add(k, p.Extensions[k], true)
+	}
+
+	return keys, values
+}
+
+// MarshalJSON flattens the extensions into the problem object.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	keys, values := p.members()
+
+	var buf bytes.Buffer
+
+	// HTML escaping is left to the encoder calling MarshalJSON, so UnEscapeHTML is honored.
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		if err := encoder.Encode(k); err != nil {
+			return nil, err
+		}
+
+		buf.WriteByte(':')
+
+		if err := encoder.Encode(values[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// MarshalXML writes the problem in the RFC 9457 XML format, with extensions as child elements.
+func (p Problem) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Space: problemNamespace, Local: "problem"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	keys, values := p.members()
+
+	for _, k := range keys {
+		value := values[k]
+
+		// Arrays are represented as a sequence of "i" elements.
+		if rv := reflect.ValueOf(value); (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8 {
+			value = struct {
+				I interface{} `xml:"i"`
+			}{value}
+		}
+
+		if err := e.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: k}}); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// Problem writes the problem details as an application/problem+json response.
+func (r *Render) Problem(w io.Writer, status int, p Problem) error {
+	return r.For(nil).Problem(w, status, p)
+}
+
+// ProblemXML writes the problem details as an application/problem+xml response.
+func (r *Render) ProblemXML(w io.Writer, status int, p Problem) error {
+	return r.For(nil).ProblemXML(w, status, p)
+}
+
+// renderProblemError writes the error as a 500 problem details response.
+func (r *Render) renderProblemError(w http.ResponseWriter, err error) {
+	w.Header().Del(ContentLength)
+
+	head := Head{
+		ContentType: ContentProblemJSON + r.compiledCharset,
+		Status:      http.StatusInternalServerError,
+	}
+
+	p := Problem{
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+		Detail: err.Error(),
+	}
+
+	// Problem details always marshal, so there is no further error to handle.
+	_ = JSON{Head: head}.Render(w, p)
+}
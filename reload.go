@@ -0,0 +1,73 @@
+package render
+
+import (
+	"sort"
+	"time"
+)
+
+// ReloadEvent describes a compile of the templates, such as a reload in development mode.
+type ReloadEvent struct {
+	// Version of the templates in use after the compile. It is incremented by every
+	// successful compile, and unchanged if the compile failed.
+	Version uint64
+	// Files lists the template files that changed, if they are known.
+	Files []string
+	// Duration of the compile.
+	Duration time.Duration
+	// Err is the compile error, or nil if the compile succeeded.
+	Err error
+}
+
+// OnReload registers a function to call after every compile of the templates, whether it
+// succeeded or not. The function is called from the goroutine compiling the templates, so
+// it should not block. OnReload returns a function that unregisters it.
+func (r *Render) OnReload(fn func(event ReloadEvent)) func() {
+	r.reloadLock.Lock()
+	defer r.reloadLock.Unlock()
+
+	if r.reloadFuncs == nil {
+		r.reloadFuncs = map[int]func(ReloadEvent){}
+	}
+
+	r.reloadID++
+	id := r.reloadID
+	r.reloadFuncs[id] = fn
+
+	return func() {
+		r.reloadLock.Lock()
+		defer r.reloadLock.Unlock()
+
+		delete(r.reloadFuncs, id)
+	}
+}
+
+// TemplateVersion returns the version of the templates in use, which starts at 1 and is
+// incremented every time the templates are compiled successfully.
+func (r *Render) TemplateVersion() uint64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.templates.version
+}
+
+func (r *Render) notifyReload(event ReloadEvent) {
+	r.reloadLock.Lock()
+
+	ids := make([]int, 0, len(r.reloadFuncs))
+	for id := range r.reloadFuncs {
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+
+	funcs := make([]func(ReloadEvent), 0, len(ids))
+	for _, id := range ids {
+		funcs = append(funcs, r.reloadFuncs[id])
+	}
+
+	r.reloadLock.Unlock()
+
+	for _, fn := range funcs {
+		fn(event)
+	}
+}
@@ -1,7 +1,9 @@
 package render
 
 import (
-	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
@@ -11,35 +13,53 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
-
-	"github.com/fsnotify/fsnotify"
+	texttemplate "text/template"
+	"time"
 )
 
 const (
 	// ContentBinary header value for binary data.
 	ContentBinary = "application/octet-stream"
+	// ContentCBOR header value for CBOR data.
+	ContentCBOR = "application/cbor"
+	// ContentCSV header value for comma separated values.
+	ContentCSV = "text/csv"
+	// ContentDisposition header constant.
+	ContentDisposition = "Content-Disposition"
+	// ContentEventStream header value for Server-Sent Events.
+	ContentEventStream = "text/event-stream"
 	// ContentHTML header value for HTML data.
 	ContentHTML = "text/html"
 	// ContentJSON header value for JSON data.
 	ContentJSON = "application/json"
 	// ContentJSONP header value for JSONP data.
 	ContentJSONP = "application/javascript"
+	// ContentNDJSON header value for newline delimited JSON data.
+	ContentNDJSON = "application/x-ndjson"
 	// ContentLength header constant.
 	ContentLength = "Content-Length"
+	// ContentMsgPack header value for MessagePack data.
+	ContentMsgPack = "application/msgpack"
 	// ContentText header value for Text data.
 	ContentText = "text/plain"
+	// ContentTSV header value for tab separated values.
+	ContentTSV = "text/tab-separated-values"
 	// ContentType header constant.
 	ContentType = "Content-Type"
 	// ContentXHTML header value for XHTML data.
 	ContentXHTML = "application/xhtml+xml"
 	// ContentXML header value for XML data.
 	ContentXML = "text/xml"
+	// ContentYAML header value for YAML data.
+	ContentYAML = "application/yaml"
 	// Default character encoding.
 	defaultCharset = "UTF-8"
 	// Buffer pool size.
 	bufferPoolSize = 32
 	// Buffer pool capacity.
 	bufferPoolCapacity = 1 << 19
+	// Interval between event stream heartbeats.
+	defaultEventStreamHeartbeat = 15 * time.Second
 )
 
 // helperFuncs had to be moved out. See helpers.go|helpers_pre16.go files.
@@ -66,6 +86,13 @@ type Options struct {
 	Layout string
 	// Extensions to parse template files from. Defaults to [".tmpl"].
 	Extensions []string
+	// Directory to load text templates. Defaults to Directory.
+	TextDirectory string
+	// Extensions to parse text template files from. Defaults to [".txt"] if TextDirectory is set, and
+	// to none otherwise, which loads no text templates.
+	TextExtensions []string
+	// Layout text template name. Will not render a layout if blank (""). Defaults to blank ("").
+	TextLayout string
 	// Funcs is a slice of FuncMaps to apply to the template upon compilation. This is useful for helper functions. Defaults to empty map.
 	Funcs []template.FuncMap
 	// Delims sets the action delimiters to the specified strings in the Delims struct.
@@ -78,24 +105,47 @@ type Options struct {
 	IndentJSON bool
 	// Outputs human readable XML. Default is false.
 	IndentXML bool
+	// Outputs human readable YAML in block style, instead of a single line in flow style. Default is false.
+	IndentYAML bool
 	// Prefixes the JSON output with the given bytes. Default is false.
 	PrefixJSON []byte
 	// Prefixes the XML output with the given bytes.
 	PrefixXML []byte
+	// Prefixes the YAML output with the given bytes. Default is false.
+	PrefixYAML []byte
 	// Allows changing the binary content type.
 	BinaryContentType string
+	// Allows changing the CBOR content type.
+	CBORContentType string
+	// Allows changing the CSV content type.
+	CSVContentType string
 	// Allows changing the HTML content type.
 	HTMLContentType string
 	// Allows changing the JSON content type.
 	JSONContentType string
 	// Allows changing the JSONP content type.
 	JSONPContentType string
+	// Allows changing the MessagePack content type.
+	MsgPackContentType string
+	// Allows changing the NDJSON content type.
+	NDJSONContentType string
 	// Allows changing the Text content type.
 	TextContentType string
+	// Allows changing the TSV content type.
+	TSVContentType string
 	// Allows changing the XML content type.
 	XMLContentType string
+	// Allows changing the YAML content type.
+	YAMLContentType string
 	// If IsDevelopment is set to true, this will recompile the templates on every request. Default is false.
 	IsDevelopment bool
+	// Interval between checks for changed templates in development mode. When set, templates are reloaded by comparing a checksum of
+	// their contents instead of watching the directory, which works with any FileSystem and with Asset. Defaults to 0, which watches
+	// the directory when templates are read from the local file system and checks every second otherwise.
+	ReloadInterval time.Duration
+	// If DevErrorPage is set to true in development mode, HTML and TextTemplate render a page showing the template compile error, and the
+	// surrounding source, until the templates compile again. Default is false.
+	DevErrorPage bool
 	// If UseMutexLock is set to true, the standard `sync.RWMutex` lock will be used instead of the lock free implementation. Default is false.
 	// Note that when `IsDevelopment` is true, the standard `sync.RWMutex` lock is always used. Lock free is only a production feature.
 	UseMutexLock bool
@@ -105,28 +155,100 @@ type Options struct {
 	HTMLTemplateOption string
 	// Streams JSON responses instead of marshalling prior to sending. Default is false.
 	StreamingJSON bool
+	// Streams HTML responses while the template executes instead of buffering them, flushing them whenever a layout yields.
+	// Errors after the response started can not be rendered anymore, and cut it short. Default is false.
+	StreamingHTML bool
 	// Require that all partials executed in the layout are implemented in all templates using the layout. Default is false.
 	RequirePartials bool
 	// Deprecated: Use the above `RequirePartials` instead of this. As of Go 1.6, blocks are built in. Default is false.
 	RequireBlocks bool
 	// Disables automatic rendering of http.StatusInternalServerError when an error occurs. Default is false.
 	DisableHTTPErrorRendering bool
+	// Renders the automatic http.StatusInternalServerError response as an application/problem+json document instead of plain text. Default is false.
+	ProblemErrors bool
+	// ErrorHandler replaces the automatic http.StatusInternalServerError response when an error occurs. The engine is nil if the error occurred before rendering began.
+	// It is not called when DisableHTTPErrorRendering is set to true. Defaults to nil.
+	ErrorHandler func(w http.ResponseWriter, err error, engine Engine)
 	// Enables using partials without the current filename suffix which allows use of the same template in multiple files. e.g {{ partial "carosuel" }} inside the home template will match carosel-home or carosel.
 	// ***NOTE*** - This option should be named RenderPartialsWithoutSuffix as that is what it does. "Prefix" is a typo. Maintaining the existing name for backwards compatibility.
 	RenderPartialsWithoutPrefix bool
 	// BufferPool to use when rendering HTML templates. If none is supplied
 	// defaults to SizedBufferPool of size 32 with 512KiB buffers.
 	BufferPool GenericBufferPool
+	// Interval between the heartbeat comments sent on idle event streams. A negative value disables them. Default is 15 seconds.
+	EventStreamHeartbeat time.Duration
+	// Compresses responses rendered through For according to the request's Accept-Encoding header. Default is false.
+	Compression bool
+	// Responses smaller than CompressionMinSize bytes are never compressed. Default is 1024.
+	CompressionMinSize int
+	// Compressors available to Compression, in order of preference. Defaults to gzip followed by deflate.
+	// BrotliCompressor and ZstdCompressor add br and zstd, and any other content coding can be added with a Compressor.
+	Compressors []Compressor
+	// Adds an ETag header to responses rendered through For, and answers matching If-None-Match requests with http.StatusNotModified. Default is false.
+	ETag bool
+	// Generates weak ETags, which are shared between compressed and uncompressed responses, instead of strong ones. Default is false.
+	WeakETag bool
+	// Formats to register by media type, in addition to the built in JSON, XML and YAML formats. Defaults to empty map.
+	Formats map[string]Format
 }
 
-// HTMLOptions is a struct for overriding some rendering Options for specific HTML call.
+// HTMLOptions is a struct for overriding some rendering Options for specific HTML or TextTemplate call.
 type HTMLOptions struct {
-	// Layout template name. Overrides Options.Layout.
+	// Layout template name. Overrides Options.Layout, or Options.TextLayout for text templates.
 	Layout string
 	// Funcs added to Options.Funcs.
 	Funcs template.FuncMap
 }
 
+// templateSets holds the compiled HTML and text templates.
+type templateSets struct {
+	// The compiled templates are never executed, so they can be cloned for the renders,
+	// which set their own functions on a clone.
+	html *template.Template
+	text *texttemplate.Template
+
+	// Clones of the compiled templates that no render is using, and the functions the
+	// templates were parsed with, which a clone is reset to before it is reused.
+	htmlClones *sync.Pool
+	textClones *sync.Pool
+	funcs      template.FuncMap
+
+	// Clones of the compiled templates returned by TemplateLookup and TextTemplateLookup.
+	lookupHTML *template.Template
+	lookupText *texttemplate.Template
+
+	// Parent layouts of the layouts that extend one, by name.
+	htmlParents map[string]string
+	textParents map[string]string
+
+	// version is incremented by every successful compile, starting at 1.
+	version uint64
+}
+
+// CompileError is returned when a template file can not be read or parsed.
+type CompileError struct {
+	// Path of the template file or asset.
+	Path string
+	// Line of the parse error, or 0 if unknown.
+	Line int
+	// Source of the template file, or nil if it could not be read.
+	Source []byte
+	// Err is the underlying read or parse error.
+	Err error
+}
+
+func newCompileError(path, name string, source []byte, err error) *CompileError {
+	return &CompileError{Path: path, Line: parseError(name, err).Line, Source: source, Err: err}
+}
+
+func (e *CompileError) Error() string {
+	return fmt.Sprintf("render: unable to compile %s: %v", e.Path, e.Err)
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
 // Render is a service that provides functions for easily writing JSON, XML,
 // binary data, and HTML templates out to a HTTP Response.
 type Render struct {
@@ -134,24 +256,55 @@ type Render struct {
 
 	// Customize Secure with an Options struct.
 	opt             Options
-	templates       *template.Template
+	templates       templateSets
 	compiledCharset string
 	hasWatcher      bool
+	watcher         *watcher
+	compileErr      error
+
+	reloadLock  sync.Mutex
+	reloadFuncs map[int]func(ReloadEvent)
+	reloadID    int
+
+	formatLock  sync.RWMutex
+	formats     map[string]Format
+	formatOrder []string
 }
 
 // New constructs a new Render instance with the supplied options.
+// It panics if the templates can not be compiled, see NewWithError.
 func New(options ...Options) *Render {
+	r, err := NewWithError(options...)
+	if err != nil {
+		// We don't want any silent server starts.
+		panic(err)
+	}
+
+	return r
+}
+
+// NewWithError constructs a new Render instance with the supplied options, and returns
+// an error instead of panicking if the templates can not be compiled.
+func NewWithError(options ...Options) (*Render, error) {
 	var o Options
 	if len(options) > 0 {
 		o = options[0]
 	}
 
-	r := Render{opt: o}
+	r := &Render{opt: o}
 
 	r.prepareOptions()
-	r.CompileTemplates()
+	r.registerFormats()
+
+	if err := r.CompileTemplates(); err != nil {
+		return nil, err
+	}
+
+	if r.opt.IsDevelopment {
+		r.watch()
+	}
 
-	return &r
+	return r, nil
 }
 
 func (r *Render) prepareOptions() {
@@ -176,10 +329,32 @@ func (r *Render) prepareOptions() {
 		r.opt.Extensions = []string{".tmpl"}
 	}
 
+	// Text templates are only loaded once a directory or extensions are set for them, so
+	// other files next to the HTML templates are not parsed.
+	if len(r.opt.TextExtensions) == 0 && len(r.opt.TextDirectory) > 0 {
+		r.opt.TextExtensions = []string{".txt"}
+	}
+
+	if len(r.opt.TextDirectory) == 0 {
+		r.opt.TextDirectory = r.opt.Directory
+	}
+
 	if len(r.opt.BinaryContentType) == 0 {
 		r.opt.BinaryContentType = ContentBinary
 	}
 
+	if len(r.opt.CBORContentType) == 0 {
+		r.opt.CBORContentType = ContentCBOR
+	}
+
+	if len(r.opt.CSVContentType) == 0 {
+		r.opt.CSVContentType = ContentCSV
+	}
+
+	if r.opt.EventStreamHeartbeat == 0 {
+		r.opt.EventStreamHeartbeat = defaultEventStreamHeartbeat
+	}
+
 	if len(r.opt.HTMLContentType) == 0 {
 		r.opt.HTMLContentType = ContentHTML
 	}
@@ -192,18 +367,42 @@ func (r *Render) prepareOptions() {
 		r.opt.JSONPContentType = ContentJSONP
 	}
 
+	if len(r.opt.MsgPackContentType) == 0 {
+		r.opt.MsgPackContentType = ContentMsgPack
+	}
+
+	if len(r.opt.NDJSONContentType) == 0 {
+		r.opt.NDJSONContentType = ContentNDJSON
+	}
+
 	if len(r.opt.TextContentType) == 0 {
 		r.opt.TextContentType = ContentText
 	}
 
+	if len(r.opt.TSVContentType) == 0 {
+		r.opt.TSVContentType = ContentTSV
+	}
+
 	if len(r.opt.XMLContentType) == 0 {
 		r.opt.XMLContentType = ContentXML
 	}
 
+	if len(r.opt.YAMLContentType) == 0 {
+		r.opt.YAMLContentType = ContentYAML
+	}
+
 	if r.opt.BufferPool == nil {
 		r.opt.BufferPool = NewSizedBufferPool(bufferPoolSize, bufferPoolCapacity)
 	}
 
+	if r.opt.CompressionMinSize == 0 {
+		r.opt.CompressionMinSize = defaultCompressionMinSize
+	}
+
+	if len(r.opt.Compressors) == 0 {
+		r.opt.Compressors = []Compressor{GzipCompressor(gzip.DefaultCompression), DeflateCompressor(zlib.DefaultCompression)}
+	}
+
 	if r.opt.IsDevelopment || r.opt.UseMutexLock {
 		r.lock = &sync.RWMutex{}
 	} else {
@@ -211,46 +410,175 @@ func (r *Render) prepareOptions() {
 	}
 }
 
-func (r *Render) CompileTemplates() {
+// CompileTemplates compiles the templates into new template sets, which replace the current ones
+// only if every template compiled. Otherwise, the current templates are kept and a *CompileError
+// is returned.
+func (r *Render) CompileTemplates() error {
+	return r.compileTemplates(nil)
+}
+
+// compileTemplates compiles the templates after the given files changed, and notifies the OnReload functions.
+func (r *Render) compileTemplates(files []string) error {
+	start := time.Now()
+
+	var err error
 	if r.opt.Asset == nil || r.opt.AssetNames == nil {
-		r.compileTemplatesFromDir()
+		err = r.compileTemplatesFromDir()
+	} else {
+		err = r.compileTemplatesFromAsset()
+	}
+
+	r.notifyReload(ReloadEvent{
+		Version:  r.TemplateVersion(),
+		Files:    files,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+
+	return err
+}
+
+func (r *Render) compileTemplatesFromDir() error {
+	templates := r.newTemplates()
+	textTemplates := r.newTextTemplates()
+
+	err := r.walkDir(r.opt.Directory, r.opt.Extensions, func(_, name string, buf []byte) error {
+		return r.addTemplate(templates, name, buf)
+	})
+
+	if err == nil && len(r.opt.TextExtensions) > 0 {
+		err = r.walkDir(r.opt.TextDirectory, r.opt.TextExtensions, func(_, name string, buf []byte) error {
+			return r.addTextTemplate(textTemplates, name, buf)
+		})
+	}
+
+	var sets templateSets
+	if err == nil {
+		sets, err = newTemplateSets(templates, textTemplates, r.templateFuncs())
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
 
-		return
+	if r.compileErr = err; err == nil {
+		sets.version = r.templates.version + 1
+		r.templates = sets
 	}
 
-	r.compileTemplatesFromAsset()
+	return err
 }
 
-func (r *Render) compileTemplatesFromDir() {
-	dir := r.opt.Directory
-	tmpTemplates := template.New(dir)
+func (r *Render) compileTemplatesFromAsset() error {
+	templates := r.newTemplates()
+	textTemplates := r.newTextTemplates()
 
-	if len(r.opt.HTMLTemplateOption) > 0 {
-		tmpTemplates.Option(r.opt.HTMLTemplateOption)
+	err := r.walkAssets(r.opt.Directory, r.opt.Extensions, func(_, name string, buf []byte) error {
+		return r.addTemplate(templates, name, buf)
+	})
+
+	if err == nil && len(r.opt.TextExtensions) > 0 {
+		err = r.walkAssets(r.opt.TextDirectory, r.opt.TextExtensions, func(_, name string, buf []byte) error {
+			return r.addTextTemplate(textTemplates, name, buf)
+		})
+	}
+
+	var sets templateSets
+	if err == nil {
+		sets, err = newTemplateSets(templates, textTemplates, r.templateFuncs())
 	}
 
-	tmpTemplates.Delims(r.opt.Delims.Left, r.opt.Delims.Right)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.compileErr = err; err == nil {
+		sets.version = r.templates.version + 1
+		r.templates = sets
+	}
 
-	var watcher *fsnotify.Watcher
+	return err
+}
 
-	if r.opt.IsDevelopment {
+// newTemplateSets collects the layouts extended by the templates, and clones the templates for lookups.
+func newTemplateSets(templates *template.Template, textTemplates *texttemplate.Template, funcs template.FuncMap) (templateSets, error) {
+	sets := templateSets{
+		html:        templates,
+		text:        textTemplates,
+		htmlClones:  &sync.Pool{},
+		textClones:  &sync.Pool{},
+		funcs:       funcs,
+		htmlParents: map[string]string{},
+		textParents: map[string]string{},
+	}
+
+	for _, t := range templates.Templates() {
+		if parent := extends(t.Tree); len(parent) > 0 {
+			sets.htmlParents[t.Name()] = parent
+		}
+	}
+
+	for _, t := range textTemplates.Templates() {
+		if parent := extends(t.Tree); len(parent) > 0 {
+			sets.textParents[t.Name()] = parent
+		}
+	}
+
+	var err error
+	if sets.lookupHTML, err = templates.Clone(); err != nil {
+		return templateSets{}, err
+	}
+
+	if sets.lookupText, err = textTemplates.Clone(); err != nil {
+		return templateSets{}, err
+	}
+
+	return sets, nil
+}
+
+// htmlClone returns a clone of the HTML templates for a single render, and a function that
+// makes it available to other renders once the render is done.
+func (s templateSets) htmlClone() (*template.Template, func(), error) {
+	templates, ok := s.htmlClones.Get().(*template.Template)
+	if !ok {
 		var err error
+		if templates, err = s.html.Clone(); err != nil {
+			return nil, nil, err
+		}
+	}
 
-		watcher, err = fsnotify.NewWatcher()
-		if err != nil {
-			log.Printf("Unable to create new watcher for template files. Templates will be recompiled on every render. Error: %v\n", err)
+	release := func() {
+		templates.Funcs(s.funcs)
+		s.htmlClones.Put(templates)
+	}
+
+	return templates, release, nil
+}
+
+// textClone is htmlClone for the text templates.
+func (s templateSets) textClone() (*texttemplate.Template, func(), error) {
+	templates, ok := s.textClones.Get().(*texttemplate.Template)
+	if !ok {
+		var err error
+		if templates, err = s.text.Clone(); err != nil {
+			return nil, nil, err
 		}
 	}
 
+	release := func() {
+		templates.Funcs(texttemplate.FuncMap(s.funcs))
+		s.textClones.Put(templates)
+	}
+
+	return templates, release, nil
+}
+
+// walkDir calls add with the path, name and contents of every file in dir matching one of the extensions.
+func (r *Render) walkDir(dir string, extensions []string, add func(path, name string, buf []byte) error) error {
 	// Walk the supplied directory and compile any files that match our extension list.
-	_ = r.opt.FileSystem.Walk(dir, func(path string, info os.FileInfo, _ error) error {
+	return r.opt.FileSystem.Walk(dir, func(path string, info os.FileInfo, _ error) error {
 		// Fix same-extension-dirs bug: some dir might be named to: "users.tmpl", "local.html".
 		// These dirs should be excluded as they are not valid golang templates, but files under
 		// them should be treat as normal.
 		// If is a dir, return immediately (dir is not a valid golang template).
-		if info != nil && watcher != nil {
-			_ = watcher.Add(path)
-		}
 		if info == nil || info.IsDir() {
 			return nil
 		}
@@ -265,63 +593,29 @@ func (r *Render) compileTemplatesFromDir() {
 			ext = filepath.Ext(rel)
 		}
 
-		for _, extension := range r.opt.Extensions {
+		for _, extension := range extensions {
 			if ext == extension {
+				name := filepath.ToSlash(rel[0 : len(rel)-len(ext)])
+
 				buf, err := r.opt.FileSystem.ReadFile(path)
-				if err != nil {
-					panic(err)
+				if err == nil {
+					err = add(path, name, buf)
 				}
 
-				name := (rel[0 : len(rel)-len(ext)])
-				tmpl := tmpTemplates.New(filepath.ToSlash(name))
-
-				// Add our funcmaps.
-				for _, funcs := range r.opt.Funcs {
-					tmpl.Funcs(funcs)
+				if err != nil {
+					return newCompileError(path, name, buf, err)
 				}
 
-				// Break out if this parsing fails. We don't want any silent server starts.
-				template.Must(tmpl.Funcs(helperFuncs()).Parse(string(buf)))
-
 				break
 			}
 		}
 
 		return nil
 	})
-
-	r.lock.Lock()
-	defer r.lock.Unlock()
-	r.templates = tmpTemplates
-
-	if r.hasWatcher = watcher != nil; r.hasWatcher {
-		go func() {
-			select {
-			case _, ok := <-watcher.Events:
-				if !ok {
-					return
-				}
-			case _, ok := <-watcher.Errors:
-				if !ok {
-					return
-				}
-			}
-			watcher.Close()
-			r.CompileTemplates()
-		}()
-	}
 }
 
-func (r *Render) compileTemplatesFromAsset() {
-	dir := r.opt.Directory
-	tmpTemplates := template.New(dir)
-
-	if len(r.opt.HTMLTemplateOption) > 0 {
-		tmpTemplates.Option(r.opt.HTMLTemplateOption)
-	}
-
-	tmpTemplates.Delims(r.opt.Delims.Left, r.opt.Delims.Right)
-
+// walkAssets calls add with the path, name and contents of every asset in dir matching one of the extensions.
+func (r *Render) walkAssets(dir string, extensions []string, add func(path, name string, buf []byte) error) error {
 	for _, path := range r.opt.AssetNames() {
 		if !strings.HasPrefix(path, dir) {
 			continue
@@ -329,7 +623,7 @@ func (r *Render) compileTemplatesFromAsset() {
 
 		rel, err := filepath.Rel(dir, path)
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		ext := ""
@@ -337,32 +631,105 @@ func (r *Render) compileTemplatesFromAsset() {
 			ext = "." + strings.Join(strings.Split(rel, ".")[1:], ".")
 		}
 
-		for _, extension := range r.opt.Extensions {
+		for _, extension := range extensions {
 			if ext == extension {
+				name := filepath.ToSlash(rel[0 : len(rel)-len(ext)])
+
 				buf, err := r.opt.Asset(path)
-				if err != nil {
-					panic(err)
+				if err == nil {
+					err = add(path, name, buf)
 				}
 
-				name := (rel[0 : len(rel)-len(ext)])
-				tmpl := tmpTemplates.New(filepath.ToSlash(name))
-
-				// Add our funcmaps.
-				for _, funcs := range r.opt.Funcs {
-					tmpl.Funcs(funcs)
+				if err != nil {
+					return newCompileError(path, name, buf, err)
 				}
 
-				// Break out if this parsing fails. We don't want any silent server starts.
-				template.Must(tmpl.Funcs(helperFuncs()).Parse(string(buf)))
-
 				break
 			}
 		}
 	}
 
-	r.lock.Lock()
-	defer r.lock.Unlock()
-	r.templates = tmpTemplates
+	return nil
+}
+
+func (r *Render) newTemplates() *template.Template {
+	templates := template.New(r.opt.Directory)
+
+	if len(r.opt.HTMLTemplateOption) > 0 {
+		templates.Option(r.opt.HTMLTemplateOption)
+	}
+
+	return templates.Delims(r.opt.Delims.Left, r.opt.Delims.Right)
+}
+
+func (r *Render) newTextTemplates() *texttemplate.Template {
+	return texttemplate.New(r.opt.TextDirectory).Delims(r.opt.Delims.Left, r.opt.Delims.Right)
+}
+
+// templateFuncs returns the functions the templates are parsed with: our funcmaps, and the
+// helpers, which take precedence.
+func (r *Render) templateFuncs() template.FuncMap {
+	funcs := template.FuncMap{}
+
+	for _, fm := range r.opt.Funcs {
+		for name, fn := range fm {
+			funcs[name] = fn
+		}
+	}
+
+	for name, fn := range helperFuncs() {
+		funcs[name] = fn
+	}
+
+	return funcs
+}
+
+func (r *Render) addTemplate(templates *template.Template, name string, buf []byte) error {
+	tmpl := templates.New(name)
+
+	// Add our funcmaps.
+	for _, funcs := range r.opt.Funcs {
+		tmpl.Funcs(funcs)
+	}
+
+	_, err := tmpl.Funcs(helperFuncs()).Parse(string(buf))
+
+	return err
+}
+
+func (r *Render) addTextTemplate(templates *texttemplate.Template, name string, buf []byte) error {
+	tmpl := templates.New(name)
+
+	// Add our funcmaps.
+	for _, funcs := range r.opt.Funcs {
+		tmpl.Funcs(texttemplate.FuncMap(funcs))
+	}
+
+	_, err := tmpl.Funcs(texttemplate.FuncMap(helperFuncs())).Parse(string(buf))
+
+	return err
+}
+
+// compiledTemplates returns the current template sets. In development mode without a
+// watcher, the templates are recompiled first. With DevErrorPage, the error of the last
+// compile is returned until the templates compile again.
+func (r *Render) compiledTemplates() (templateSets, error) {
+	r.lock.RLock() // rlock here because we're reading the hasWatcher
+	if r.opt.IsDevelopment && !r.hasWatcher {
+		r.lock.RUnlock() // runlock here because CompileTemplates will lock
+		if err := r.CompileTemplates(); err != nil {
+			log.Printf("Unable to recompile templates. The last good templates are still used. Error: %v\n", err)
+		}
+		r.lock.RLock()
+	}
+
+	defer r.lock.RUnlock()
+
+	if r.opt.IsDevelopment && r.opt.DevErrorPage {
+		return r.templates, r.compileErr
+	}
+
+	return r.templates, nil
 }
 
 // TemplateLookup is a wrapper around template.Lookup and returns
@@ -372,58 +739,77 @@ func (r *Render) TemplateLookup(t string) *template.Template {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
 
-	return r.templates.Lookup(t)
+	return r.templates.lookupHTML.Lookup(t)
 }
 
-func (r *Render) execute(templates *template.Template, name string, binding interface{}) (*bytes.Buffer, error) {
-	buf := new(bytes.Buffer)
+// TextTemplateLookup returns the text template with the given name, or nil if there is no such template.
+func (r *Render) TextTemplateLookup(t string) *texttemplate.Template {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
 
-	return buf, templates.ExecuteTemplate(buf, name, binding)
+	return r.templates.lookupText.Lookup(t)
 }
 
-func (r *Render) layoutFuncs(templates *template.Template, name string, binding interface{}) template.FuncMap {
+func (r *Render) layoutFuncs(l layout) template.FuncMap {
 	return template.FuncMap{
-		"yield": func() (template.HTML, error) {
-			buf, err := r.execute(templates, name, binding)
+		"yield": func(args ...interface{}) (template.HTML, error) {
+			if len(args) > 0 {
+				s, err := l.yieldSection(args, htmlFallback)
+
+				// Return safe HTML here since we are rendering our own template.
+				return template.HTML(s), err
+			}
+
+			s, err := l.yield()
 
 			// Return safe HTML here since we are rendering our own template.
-			return template.HTML(buf.String()), err
-		},
-		"current": func() (string, error) {
-			return name, nil
+			return template.HTML(s), err
 		},
+		"current": l.current,
 		"block": func(partialName string) (template.HTML, error) {
 			log.Println("Render's `block` implementation is now depericated. Use `partial` as a drop in replacement.")
-			fullPartialName := fmt.Sprintf("%s-%s", partialName, name)
-			if templates.Lookup(fullPartialName) == nil && r.opt.RenderPartialsWithoutPrefix {
-				fullPartialName = partialName
-			}
-			if r.opt.RequireBlocks || templates.Lookup(fullPartialName) != nil {
-				buf, err := r.execute(templates, fullPartialName, binding)
-				// Return safe HTML here since we are rendering our own template.
-				return template.HTML(buf.String()), err
-			}
+			s, err := l.partial(partialName, r.opt.RequireBlocks)
 
-			return "", nil
+			// Return safe HTML here since we are rendering our own template.
+			return template.HTML(s), err
 		},
 		"partial": func(partialName string) (template.HTML, error) {
-			fullPartialName := fmt.Sprintf("%s-%s", partialName, name)
-			if templates.Lookup(fullPartialName) == nil && r.opt.RenderPartialsWithoutPrefix {
-				fullPartialName = partialName
-			}
-			if r.opt.RequirePartials || templates.Lookup(fullPartialName) != nil {
-				buf, err := r.execute(templates, fullPartialName, binding)
-				// Return safe HTML here since we are rendering our own template.
-				return template.HTML(buf.String()), err
+			s, err := l.partial(partialName, r.opt.RequirePartials)
+
+			// Return safe HTML here since we are rendering our own template.
+			return template.HTML(s), err
+		},
+	}
+}
+
+func (r *Render) textLayoutFuncs(l layout) texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"yield": func(args ...interface{}) (string, error) {
+			if len(args) > 0 {
+				return l.yieldSection(args, func(fallback interface{}) string {
+					return fmt.Sprint(fallback)
+				})
 			}
 
-			return "", nil
+			return l.yield()
+		},
+		"current": l.current,
+		"partial": func(partialName string) (string, error) {
+			return l.partial(partialName, r.opt.RequirePartials)
 		},
 	}
 }
 
-func (r *Render) prepareHTMLOptions(htmlOpt []HTMLOptions) HTMLOptions {
-	layout := r.opt.Layout
+// htmlFallback escapes the fallback of a yield, unless it is template.HTML already.
+func htmlFallback(fallback interface{}) string {
+	if h, ok := fallback.(template.HTML); ok {
+		return string(h)
+	}
+
+	return template.HTMLEscapeString(fmt.Sprint(fallback))
+}
+
+func (r *Render) prepareHTMLOptions(layout string, htmlOpt []HTMLOptions) HTMLOptions {
 	funcs := template.FuncMap{}
 
 	for _, tmp := range r.opt.Funcs {
@@ -451,128 +837,169 @@ func (r *Render) prepareHTMLOptions(htmlOpt []HTMLOptions) HTMLOptions {
 
 // Render is the generic function called by XML, JSON, Data, HTML, and can be called by custom implementations.
 func (r *Render) Render(w io.Writer, e Engine, data interface{}) error {
-	err := e.Render(w, data)
-	if hw, ok := w.(http.ResponseWriter); err != nil && !r.opt.DisableHTTPErrorRendering && ok {
-		http.Error(hw, err.Error(), http.StatusInternalServerError)
+	return r.render(w, nil, e, data)
+}
+
+// render renders the data with the engine, passing the request along to a RequestEngine.
+// Other engines are stopped by renderContext once the request's context is done.
+func (r *Render) render(w io.Writer, req *http.Request, e Engine, data interface{}) error {
+	var err error
+
+	switch re, ok := e.(RequestEngine); {
+	case req == nil:
+		err = e.Render(w, data)
+	case ok:
+		err = re.RenderRequest(w, req, data)
+	default:
+		err = renderContext(req.Context(), w, e, data)
+	}
+
+	// The status of a streaming response that started is sent already, so the error can not be rendered anymore.
+	var se streamError
+	if errors.As(err, &se) {
+		return se.err
+	}
+
+	// There is no point in rendering an error once the request's context is done.
+	if hw, ok := w.(http.ResponseWriter); err != nil && !r.opt.DisableHTTPErrorRendering && !isContextError(err) && ok {
+		r.renderError(hw, err, e)
 	}
 
 	return err
 }
 
-// Data writes out the raw bytes as binary data.
-func (r *Render) Data(w io.Writer, status int, v []byte) error {
-	head := Head{
-		ContentType: r.opt.BinaryContentType,
-		Status:      status,
+// renderError writes the response for an error returned by the engine.
+func (r *Render) renderError(w http.ResponseWriter, err error, e Engine) {
+	switch {
+	case r.opt.ErrorHandler != nil:
+		r.opt.ErrorHandler(w, err, e)
+	case r.opt.ProblemErrors:
+		r.renderProblemError(w, err)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
+}
 
-	d := Data{
+// CBOR marshals the given interface object and writes the CBOR response.
+func (r *Render) CBOR(w io.Writer, status int, v interface{}) error {
+	return r.For(nil).CBOR(w, status, v)
+}
+
+func (r *Render) newCBOR(head Head) Engine { //nolint:ireturn
+	return CBOR{
 		Head: head,
 	}
+}
+
+// Data writes out the raw bytes as binary data.
+func (r *Render) Data(w io.Writer, status int, v []byte) error {
+	return r.For(nil).Data(w, status, v)
+}
 
-	return r.Render(w, d, v)
+// EventStream sends the events as Server-Sent Events until the channel is closed or the
+// request's context is done. Event data is JSON encoded with the same options as JSON.
+func (r *Render) EventStream(w http.ResponseWriter, req *http.Request, events <-chan Event) error {
+	return r.For(req).EventStream(w, events)
 }
 
 // HTML builds up the response from the specified template and bindings.
 func (r *Render) HTML(w io.Writer, status int, name string, binding interface{}, htmlOpt ...HTMLOptions) error {
-	// If we are in development mode, recompile the templates on every HTML request.
-	r.lock.RLock() // rlock here because we're reading the hasWatcher
-	if r.opt.IsDevelopment && !r.hasWatcher {
-		r.lock.RUnlock() // runlock here because CompileTemplates will lock
-		r.CompileTemplates()
-		r.lock.RLock()
-	}
-
-	templates := r.templates
-	r.lock.RUnlock()
-
-	opt := r.prepareHTMLOptions(htmlOpt)
-	if tpl := templates.Lookup(name); tpl != nil {
-		if len(opt.Layout) > 0 {
-			tpl.Funcs(r.layoutFuncs(templates, name, binding))
-			name = opt.Layout
-		}
-
-		if len(opt.Funcs) > 0 {
-			tpl.Funcs(opt.Funcs)
-		}
-	}
-
-	head := Head{
-		ContentType: r.opt.HTMLContentType + r.compiledCharset,
-		Status:      status,
-	}
+	return r.For(nil).HTML(w, status, name, binding, htmlOpt...)
+}
 
-	h := HTML{
-		Head:      head,
-		Name:      name,
-		Templates: templates,
-		bp:        r.opt.BufferPool,
-	}
+// HTMLBytes executes the specified template and bindings like HTML, and returns the result
+// instead of writing a response.
+func (r *Render) HTMLBytes(name string, binding interface{}, htmlOpt ...HTMLOptions) ([]byte, error) {
+	return r.For(nil).HTMLBytes(name, binding, htmlOpt...)
+}
 
-	return r.Render(w, h, binding)
+// HTMLString executes the specified template and bindings like HTML, and returns the result
+// instead of writing a response.
+func (r *Render) HTMLString(name string, binding interface{}, htmlOpt ...HTMLOptions) (string, error) {
+	return r.For(nil).HTMLString(name, binding, htmlOpt...)
 }
 
 // JSON marshals the given interface object and writes the JSON response.
 func (r *Render) JSON(w io.Writer, status int, v interface{}) error {
-	head := Head{
-		ContentType: r.opt.JSONContentType + r.compiledCharset,
-		Status:      status,
-	}
+	return r.For(nil).JSON(w, status, v)
+}
 
-	j := JSON{
+func (r *Render) newJSON(head Head) Engine { //nolint:ireturn
+	return JSON{
 		Head:          head,
 		Indent:        r.opt.IndentJSON,
 		Prefix:        r.opt.PrefixJSON,
 		UnEscapeHTML:  r.opt.UnEscapeHTML,
 		StreamingJSON: r.opt.StreamingJSON,
 	}
-
-	return r.Render(w, j, v)
 }
 
 // JSONP marshals the given interface object and writes the JSON response.
 func (r *Render) JSONP(w io.Writer, status int, callback string, v interface{}) error {
-	head := Head{
-		ContentType: r.opt.JSONPContentType + r.compiledCharset,
-		Status:      status,
-	}
+	return r.For(nil).JSONP(w, status, callback, v)
+}
+
+// MsgPack marshals the given interface object and writes the MessagePack response.
+func (r *Render) MsgPack(w io.Writer, status int, v interface{}) error {
+	return r.For(nil).MsgPack(w, status, v)
+}
 
-	j := JSONP{
-		Head:     head,
-		Indent:   r.opt.IndentJSON,
-		Callback: callback,
+func (r *Render) newMsgPack(head Head) Engine { //nolint:ireturn
+	return MsgPack{
+		Head: head,
 	}
+}
 
-	return r.Render(w, j, v)
+// NDJSON writes each item of the given channel, slice, array or Iterator as a line of JSON,
+// flushing the response after every item.
+func (r *Render) NDJSON(w io.Writer, status int, v interface{}) error {
+	return r.For(nil).NDJSON(w, status, v)
 }
 
 // Text writes out a string as plain text.
 func (r *Render) Text(w io.Writer, status int, v string) error {
-	head := Head{
-		ContentType: r.opt.TextContentType + r.compiledCharset,
-		Status:      status,
-	}
+	return r.For(nil).Text(w, status, v)
+}
 
-	t := Text{
-		Head: head,
-	}
+// TextTemplate builds up the response from the specified text template and bindings.
+func (r *Render) TextTemplate(w io.Writer, status int, name string, binding interface{}, htmlOpt ...HTMLOptions) error {
+	return r.For(nil).TextTemplate(w, status, name, binding, htmlOpt...)
+}
+
+// TextTemplateBytes executes the specified text template and bindings like TextTemplate, and
+// returns the result instead of writing a response.
+func (r *Render) TextTemplateBytes(name string, binding interface{}, htmlOpt ...HTMLOptions) ([]byte, error) {
+	return r.For(nil).TextTemplateBytes(name, binding, htmlOpt...)
+}
 
-	return r.Render(w, t, v)
+// TextTemplateString executes the specified text template and bindings like TextTemplate, and
+// returns the result instead of writing a response.
+func (r *Render) TextTemplateString(name string, binding interface{}, htmlOpt ...HTMLOptions) (string, error) {
+	return r.For(nil).TextTemplateString(name, binding, htmlOpt...)
 }
 
 // XML marshals the given interface object and writes the XML response.
 func (r *Render) XML(w io.Writer, status int, v interface{}) error {
-	head := Head{
-		ContentType: r.opt.XMLContentType + r.compiledCharset,
-		Status:      status,
-	}
+	return r.For(nil).XML(w, status, v)
+}
 
-	x := XML{
+func (r *Render) newXML(head Head) Engine { //nolint:ireturn
+	return XML{
 		Head:   head,
 		Indent: r.opt.IndentXML,
 		Prefix: r.opt.PrefixXML,
 	}
+}
 
-	return r.Render(w, x, v)
+// YAML marshals the given interface object and writes the YAML response.
+func (r *Render) YAML(w io.Writer, status int, v interface{}) error {
+	return r.For(nil).YAML(w, status, v)
+}
+
+func (r *Render) newYAML(head Head) Engine { //nolint:ireturn
+	return YAML{
+		Head:   head,
+		Indent: r.opt.IndentYAML,
+		Prefix: r.opt.PrefixYAML,
+	}
 }
@@ -0,0 +1,58 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestCBORBasic(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.CBOR(w, 299, Greeting{"hello", "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, 299)
+	expect(t, res.Header().Get(ContentType), ContentCBOR)
+
+	var out map[string]string
+	expectNil(t, cbor.Unmarshal(res.Body.Bytes(), &out))
+	expect(t, out["one"], "hello")
+	expect(t, out["two"], "world")
+}
+
+func TestCBORError(t *testing.T) {
+	render := New()
+
+	res := httptest.NewRecorder()
+
+	err := render.CBOR(res, http.StatusOK, make(chan int))
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+}
+
+func TestNegotiateCBOR(t *testing.T) {
+	render := New()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(Accept, "application/cbor, application/json;q=0.9")
+
+	err := render.Negotiate(res, req, http.StatusOK, Greeting{"hello", "world"})
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), ContentCBOR)
+
+	var out Greeting
+	expectNil(t, cbor.Unmarshal(res.Body.Bytes(), &out))
+	expect(t, out, Greeting{"hello", "world"})
+}
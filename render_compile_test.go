@@ -0,0 +1,161 @@
+package render
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewWithError(t *testing.T) {
+	render, err := NewWithError(Options{
+		Directory: "testdata/basic",
+	})
+
+	expectNil(t, err)
+	expectNotNil(t, render.TemplateLookup("hello"))
+}
+
+func TestNewWithErrorParseError(t *testing.T) {
+	render, err := NewWithError(Options{
+		Directory: "testdata/invalid",
+	})
+
+	var compileErr *CompileError
+
+	expect(t, render == nil, true)
+	expect(t, errors.As(err, &compileErr), true)
+	expect(t, compileErr.Path, filepath.Join("testdata", "invalid", "about.tmpl"))
+	expect(t, compileErr.Line, 2)
+}
+
+func TestNewWithErrorAssetError(t *testing.T) {
+	_, err := NewWithError(Options{
+		Asset: func(file string) ([]byte, error) {
+			return nil, os.ErrNotExist
+		},
+		AssetNames: func() []string {
+			return []string{"templates/test.tmpl"}
+		},
+	})
+
+	var compileErr *CompileError
+
+	expect(t, errors.As(err, &compileErr), true)
+	expect(t, compileErr.Path, "templates/test.tmpl")
+	expect(t, errors.Is(err, os.ErrNotExist), true)
+}
+
+func TestNewPanics(t *testing.T) {
+	defer func() {
+		expectNotNil(t, recover())
+	}()
+
+	New(Options{
+		Directory: "testdata/invalid",
+	})
+}
+
+func TestCompileTemplatesKeepsLastGood(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hello.tmpl")
+
+	expectNil(t, os.WriteFile(path, []byte("<h1>Hello {{ . }}</h1>"), 0o600))
+
+	render := New(Options{
+		Directory: dir,
+	})
+
+	expectNil(t, os.WriteFile(path, []byte("<h1>Hello {{ . </h1>"), 0o600))
+
+	var compileErr *CompileError
+
+	expect(t, errors.As(render.CompileTemplates(), &compileErr), true)
+	expect(t, compileErr.Path, path)
+
+	out, err := render.HTMLString("hello", "gophers")
+	expectNil(t, err)
+	expect(t, out, "<h1>Hello gophers</h1>")
+
+	expectNil(t, os.WriteFile(path, []byte("<h1>Goodbye {{ . }}</h1>"), 0o600))
+	expectNil(t, render.CompileTemplates())
+
+	out, err = render.HTMLString("hello", "gophers")
+	expectNil(t, err)
+	expect(t, out, "<h1>Goodbye gophers</h1>")
+}
+
+func TestDevErrorPage(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hello.tmpl")
+
+	expectNil(t, os.WriteFile(path, []byte("<h1>Hello {{ . }}</h1>"), 0o600))
+
+	render := New(Options{
+		Directory:      dir,
+		IsDevelopment:  true,
+		DevErrorPage:   true,
+		ReloadInterval: 10 * time.Millisecond,
+	})
+	defer render.Close()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = render.HTML(w, http.StatusOK, "hello", "<gophers>")
+	})
+
+	expectNil(t, os.WriteFile(path, []byte("line one\n<h1>Hello {{ . </h1>\nline three"), 0o600))
+
+	var compileErr *CompileError
+
+	eventually(t, func() bool {
+		_, err := render.HTMLString("hello", nil)
+
+		return errors.As(err, &compileErr)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Header().Get(ContentType), ContentHTML+"; charset=UTF-8")
+	expect(t, strings.Contains(res.Body.String(), "<code>"+path+":2</code>"), true)
+	expect(t, strings.Contains(res.Body.String(), `<span class="current">   2  &lt;h1&gt;Hello {{ . &lt;/h1&gt;</span>`), true)
+	expect(t, strings.Contains(res.Body.String(), "<span>   3  line three</span>"), true)
+
+	expectNil(t, os.WriteFile(path, []byte("<h1>Fixed {{ . }}</h1>"), 0o600))
+
+	eventually(t, func() bool {
+		res = httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+
+		return res.Code == http.StatusOK
+	})
+
+	expect(t, res.Body.String(), "<h1>Fixed &lt;gophers&gt;</h1>")
+}
+
+func TestDevErrorPageDisabled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hello.tmpl")
+
+	expectNil(t, os.WriteFile(path, []byte("<h1>Hello {{ . }}</h1>"), 0o600))
+
+	render := New(Options{
+		Directory:     dir,
+		IsDevelopment: true,
+	})
+
+	expectNil(t, render.Close())
+
+	expectNil(t, os.WriteFile(path, []byte("<h1>Hello {{ . </h1>"), 0o600))
+	expectNotNil(t, render.CompileTemplates())
+
+	out, err := render.HTMLString("hello", "gophers")
+	expectNil(t, err)
+	expect(t, out, "<h1>Hello gophers</h1>")
+}
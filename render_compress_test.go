@@ -0,0 +1,247 @@
+package render
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompressionGzip(t *testing.T) {
+	render := New(Options{
+		Compression: true,
+	})
+
+	body := strings.Repeat("hello world ", 200)
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.For(r).Text(w, 299, body)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(AcceptEncoding, "deflate;q=0.5, gzip")
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, 299)
+	expect(t, res.Header().Get(ContentType), ContentText+"; charset=UTF-8")
+	expect(t, res.Header().Get(ContentEncoding), "gzip")
+	expect(t, res.Header().Get(ContentLength), "")
+	expect(t, res.Header().Get("Vary"), AcceptEncoding)
+
+	gz, gzErr := gzip.NewReader(res.Body)
+	expectNil(t, gzErr)
+
+	output, _ := io.ReadAll(gz)
+	expect(t, string(output), body)
+}
+
+func TestCompressionDeflateHTML(t *testing.T) {
+	render := New(Options{
+		Directory:          "testdata/basic",
+		Compression:        true,
+		CompressionMinSize: 1,
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.For(r).HTML(w, http.StatusOK, "hello", "gophers")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(AcceptEncoding, "gzip;q=0, *")
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Header().Get(ContentType), ContentHTML+"; charset=UTF-8")
+	expect(t, res.Header().Get(ContentEncoding), "deflate")
+
+	zr, zErr := zlib.NewReader(res.Body)
+	expectNil(t, zErr)
+
+	output, _ := io.ReadAll(zr)
+	expect(t, string(output), "<h1>Hello gophers</h1>\n")
+}
+
+func TestCompressionBrotli(t *testing.T) {
+	render := New(Options{
+		Compression: true,
+		Compressors: []Compressor{BrotliCompressor(42), GzipCompressor(gzip.BestSpeed)},
+	})
+
+	body := strings.Repeat("hello world ", 200)
+
+	// The second response reuses the pooled writer.
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+		req.Header.Set(AcceptEncoding, "gzip, deflate, br")
+
+		err := render.For(req).Text(res, http.StatusOK, body)
+		expectNil(t, err)
+		expect(t, res.Header().Get(ContentEncoding), "br")
+
+		output, _ := io.ReadAll(brotli.NewReader(res.Body))
+		expect(t, string(output), body)
+	}
+}
+
+func TestCompressionZstd(t *testing.T) {
+	render := New(Options{
+		Compression: true,
+		Compressors: []Compressor{ZstdCompressor(42), GzipCompressor(gzip.BestSpeed)},
+	})
+
+	body := strings.Repeat("hello world ", 200)
+
+	// The second response reuses the pooled writer.
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+		req.Header.Set(AcceptEncoding, "gzip, deflate, zstd")
+
+		err := render.For(req).Text(res, http.StatusOK, body)
+		expectNil(t, err)
+		expect(t, res.Header().Get(ContentEncoding), "zstd")
+
+		zr, err := zstd.NewReader(res.Body)
+		expectNil(t, err)
+
+		output, _ := io.ReadAll(zr)
+		zr.Close()
+		expect(t, string(output), body)
+	}
+}
+
+func TestCompressionSkipped(t *testing.T) {
+	render := New(Options{
+		Compression: true,
+	})
+
+	large := []byte(strings.Repeat("a", 2048))
+
+	for _, test := range []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           []byte
+	}{
+		{"below minimum size", "gzip", "", []byte("small")},
+		{"not accepted", "identity", "", large},
+		{"disallowed", "gzip;q=0", "", large},
+		{"already compressed", "gzip", "image/png", large},
+	} {
+		var err error
+
+		h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(test.contentType) > 0 {
+				w.Header().Set(ContentType, test.contentType)
+			}
+
+			err = render.For(r).Data(w, http.StatusOK, test.body)
+		})
+
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+		req.Header.Set(AcceptEncoding, test.acceptEncoding)
+		h.ServeHTTP(res, req)
+
+		expectNil(t, err)
+		expect(t, res.Header().Get(ContentEncoding), "")
+		expect(t, res.Header().Get("Vary"), AcceptEncoding)
+		expect(t, res.Body.String(), string(test.body))
+	}
+}
+
+func TestCompressionDisabled(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.For(r).JSON(w, http.StatusOK, strings.Repeat("a", 2048))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(AcceptEncoding, "gzip")
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentEncoding), "")
+	expect(t, res.Header().Get("Vary"), "")
+}
+
+func TestCompressionCustomCompressor(t *testing.T) {
+	render := New(Options{
+		Compression:        true,
+		CompressionMinSize: 1,
+		Compressors: []Compressor{{
+			Encoding: "upper",
+			NewWriter: func(w io.Writer) io.WriteCloser {
+				return upperWriter{w}
+			},
+		}},
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.For(r).XML(w, http.StatusOK, GreetingXML{One: "hello", Two: "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(AcceptEncoding, "gzip, UPPER")
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentEncoding), "upper")
+	expect(t, res.Body.String(), "<GREETING ONE=\"HELLO\" TWO=\"WORLD\"></GREETING>")
+}
+
+func TestCompressionWithError(t *testing.T) {
+	render := New(Options{
+		Directory:          "testdata/basic",
+		Compression:        true,
+		CompressionMinSize: 1,
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.For(r).HTML(w, http.StatusOK, "nope", nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Body.String(), "html/template: \"nope\" is undefined\n")
+}
+
+type upperWriter struct {
+	io.Writer
+}
+
+func (u upperWriter) Write(p []byte) (int, error) {
+	return u.Writer.Write([]byte(strings.ToUpper(string(p))))
+}
+
+func (upperWriter) Close() error {
+	return nil
+}
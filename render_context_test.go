@@ -0,0 +1,183 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContextCanceledHTML(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+		ETag:      true,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+
+	err := render.For(req).HTML(res, http.StatusOK, "hello", "gophers")
+	expect(t, err, context.Canceled)
+	expect(t, res.Header().Get(ContentType), "")
+	expect(t, res.Body.String(), "")
+
+	_, err = render.For(req).HTMLString("hello", "gophers")
+	expect(t, err, context.Canceled)
+}
+
+func TestContextCanceledDuringTemplate(t *testing.T) {
+	dir := t.TempDir()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "layout.tmpl"), []byte("<main>{{ yield }}</main>"), 0o600))
+	expectNil(t, os.WriteFile(filepath.Join(dir, "slow.tmpl"), []byte("{{ range . }}{{ step }}{{ end }}"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	steps := 0
+
+	render := New(Options{
+		Directory: dir,
+		Layout:    "layout",
+		Funcs: []template.FuncMap{{
+			"step": func() string {
+				steps++
+				if steps == 2 {
+					cancel()
+				}
+
+				return "step"
+			},
+		}},
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+
+	err := render.For(req).HTML(res, http.StatusOK, "slow", make([]int, 10))
+	expect(t, err, context.Canceled)
+	expect(t, steps, 2)
+	expect(t, res.Body.String(), "")
+}
+
+func TestContextCanceledEngines(t *testing.T) {
+	render := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+
+	rr := render.For(req)
+
+	for name, fn := range map[string]func(w http.ResponseWriter) error{
+		"text":  func(w http.ResponseWriter) error { return rr.Text(w, http.StatusOK, "hello") },
+		"xml":   func(w http.ResponseWriter) error { return rr.XML(w, http.StatusOK, Greeting{"hello", "world"}) },
+		"data":  func(w http.ResponseWriter) error { return rr.Data(w, http.StatusOK, []byte("hello")) },
+		"jsonp": func(w http.ResponseWriter) error { return rr.JSONP(w, http.StatusOK, "cb", "hello") },
+	} {
+		res := httptest.NewRecorder()
+
+		err := fn(res)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
+		}
+
+		expect(t, res.Header().Get(ContentType), "")
+		expect(t, res.Body.String(), "")
+	}
+}
+
+// cancelingValue cancels the context while it is marshaled.
+type cancelingValue struct {
+	cancel context.CancelFunc
+}
+
+func (v cancelingValue) MarshalJSON() ([]byte, error) {
+	v.cancel()
+
+	return []byte(`"hello"`), nil
+}
+
+func TestContextCanceledDuringMarshal(t *testing.T) {
+	render := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+
+	err := render.For(req).JSONP(res, http.StatusOK, "cb", cancelingValue{cancel})
+	expect(t, err, context.Canceled)
+	expect(t, res.Body.String(), "")
+}
+
+func TestContextCanceledStreamingJSON(t *testing.T) {
+	render := New(Options{
+		StreamingJSON: true,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+
+	err := render.For(req).JSON(res, http.StatusOK, Greeting{"hello", "world"})
+	expect(t, err, context.Canceled)
+	expect(t, res.Header().Get(ContentType), "")
+	expect(t, res.Body.String(), "")
+}
+
+func TestContextDeadlineNDJSON(t *testing.T) {
+	render := New()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	items := make(chan int, 1)
+	items <- 1
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+
+	err := render.For(req).NDJSON(res, http.StatusOK, items)
+	expect(t, errors.Is(err, context.DeadlineExceeded), true)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "1\n")
+}
+
+func TestContextWithoutRequest(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := httptest.NewRecorder()
+
+	err := render.Render(res, HTML{
+		Head:      Head{ContentType: ContentHTML, Status: http.StatusOK},
+		Name:      "hello",
+		Templates: render.TemplateLookup("hello"),
+		Context:   ctx,
+	}, "gophers")
+	expect(t, err, context.Canceled)
+	expect(t, res.Body.String(), "")
+
+	res = httptest.NewRecorder()
+
+	err = render.HTML(res, http.StatusOK, "hello", "gophers")
+	expectNil(t, err)
+	expect(t, res.Body.String(), "<h1>Hello gophers</h1>\n")
+}
@@ -0,0 +1,170 @@
+package render
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type Identity struct {
+	ID int `csv:"id"`
+}
+
+type csvUser struct {
+	Identity
+	Name    string     `csv:"name"`
+	Email   *string    `csv:"email"`
+	Created time.Time  `csv:"created"`
+	Deleted *time.Time `csv:"deleted"`
+	Secret  string     `csv:"-"`
+	Admin   bool
+	note    string
+}
+
+func TestCSVRows(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.CSV(w, 299, [][]string{{"name", "quote"}, {"gopher", "say \"hi\", then bye"}})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, 299)
+	expect(t, res.Header().Get(ContentType), ContentCSV+"; charset=UTF-8")
+	expect(t, res.Header().Get(ContentDisposition), "")
+	expect(t, res.Body.String(), "name,quote\ngopher,\"say \"\"hi\"\", then bye\"\n")
+}
+
+func TestCSVStructs(t *testing.T) {
+	render := New()
+
+	email := "gopher@example.com"
+	created := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	users := []*csvUser{
+		{Identity{1}, "gopher", &email, created, nil, "secret", true, "note"},
+		{Identity{2}, "ferris", nil, created, &created, "secret", false, "note"},
+	}
+
+	res := httptest.NewRecorder()
+
+	err := render.CSV(res, http.StatusOK, users)
+	expectNil(t, err)
+	expect(t, res.Body.String(), "id,name,email,created,deleted,Admin\n"+
+		"1,gopher,gopher@example.com,2009-11-10T23:00:00Z,,true\n"+
+		"2,ferris,,2009-11-10T23:00:00Z,2009-11-10T23:00:00Z,false\n")
+
+	res = httptest.NewRecorder()
+
+	err = render.CSV(res, http.StatusOK, []csvUser{})
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "id,name,email,created,deleted,Admin\n")
+}
+
+func TestCSVIterator(t *testing.T) {
+	render := New()
+
+	i := 0
+	next := Iterator(func() (interface{}, error) {
+		if i == 2 {
+			return nil, io.EOF
+		}
+		i++
+
+		return []interface{}{i, "row", nil}, nil
+	})
+
+	res := httptest.NewRecorder()
+
+	err := render.CSV(res, http.StatusOK, next)
+	expectNil(t, err)
+	expect(t, res.Body.String(), "1,row,\n2,row,\n")
+}
+
+func TestCSVOptions(t *testing.T) {
+	render := New(Options{
+		CSVContentType: "application/csv",
+	})
+
+	res := httptest.NewRecorder()
+
+	err := render.CSV(res, http.StatusOK, [][]string{{"a", "b"}}, CSVOptions{
+		Delimiter: ';',
+		BOM:       true,
+		Filename:  "report 2024.csv",
+	})
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), "application/csv; charset=UTF-8")
+	expect(t, res.Header().Get(ContentDisposition), `attachment; filename="report 2024.csv"`)
+	expect(t, res.Body.String(), "\ufeffa;b\n")
+}
+
+func TestTSV(t *testing.T) {
+	render := New()
+
+	res := httptest.NewRecorder()
+
+	err := render.TSV(res, http.StatusOK, [][]string{{"a", "b c"}, {"1", "2"}})
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), ContentTSV+"; charset=UTF-8")
+	expect(t, res.Body.String(), "a\tb c\n1\t2\n")
+}
+
+func TestCSVErrors(t *testing.T) {
+	render := New()
+
+	res := httptest.NewRecorder()
+
+	err := render.CSV(res, http.StatusOK, "not a table", CSVOptions{Filename: "report.csv"})
+	expect(t, err.Error(), "csv: unsupported type string")
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Header().Get(ContentDisposition), "")
+
+	res = httptest.NewRecorder()
+
+	err = render.CSV(res, http.StatusOK, []interface{}{[]string{"a"}, 42})
+	expect(t, err.Error(), "csv: unsupported row type int")
+	expect(t, res.Code, http.StatusInternalServerError)
+
+	res = httptest.NewRecorder()
+
+	err = render.CSV(res, http.StatusOK, []interface{}{Identity{1}, Greeting{"hello", "world"}})
+	expect(t, err.Error(), "csv: row of type render.Greeting in a table of render.Identity")
+	expect(t, res.Code, http.StatusInternalServerError)
+}
+
+func TestCSVErrorAfterOutput(t *testing.T) {
+	render := New()
+
+	long := make([]byte, 8192)
+	for i := range long {
+		long[i] = 'a'
+	}
+
+	i := 0
+	next := Iterator(func() (interface{}, error) {
+		i++
+		if i == 1 {
+			return []string{string(long)}, nil
+		}
+
+		return nil, errors.New("query failed")
+	})
+
+	res := httptest.NewRecorder()
+
+	err := render.CSV(res, http.StatusOK, next)
+	expect(t, err.Error(), "query failed")
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.Len() >= len(long), true)
+}
@@ -0,0 +1,171 @@
+package render
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestETagStrong(t *testing.T) {
+	render := New(Options{
+		ETag: true,
+	})
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = render.For(r).JSON(w, http.StatusOK, Greeting{"hello", "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	etag := res.Header().Get(ETag)
+
+	expect(t, res.Code, http.StatusOK)
+	expect(t, strings.HasPrefix(etag, `"`), true)
+	expect(t, len(etag), 34)
+	expect(t, res.Body.String(), "{\"one\":\"hello\",\"two\":\"world\"}")
+
+	res = httptest.NewRecorder()
+	req.Header.Set(IfNoneMatch, `"other", `+etag)
+	h.ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusNotModified)
+	expect(t, res.Header().Get(ETag), etag)
+	expect(t, res.Header().Get(ContentType), "")
+	expect(t, res.Body.String(), "")
+
+	res = httptest.NewRecorder()
+	req.Header.Set(IfNoneMatch, `"other"`)
+	h.ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "{\"one\":\"hello\",\"two\":\"world\"}")
+}
+
+func TestETagWeakWithCompression(t *testing.T) {
+	render := New(Options{
+		ETag:               true,
+		WeakETag:           true,
+		Compression:        true,
+		CompressionMinSize: 1,
+	})
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = render.For(r).Text(w, http.StatusOK, "hello")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	etag := res.Header().Get(ETag)
+	expect(t, strings.HasPrefix(etag, `W/"`), true)
+
+	res = httptest.NewRecorder()
+	req.Header.Set(AcceptEncoding, "gzip")
+	h.ServeHTTP(res, req)
+
+	expect(t, res.Header().Get(ContentEncoding), "gzip")
+	expect(t, res.Header().Get(ETag), etag)
+
+	res = httptest.NewRecorder()
+	req.Header.Set(IfNoneMatch, strings.TrimPrefix(etag, "W/"))
+	h.ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusNotModified)
+	expect(t, res.Header().Get(ContentEncoding), "")
+	expect(t, res.Header().Get("Vary"), AcceptEncoding)
+}
+
+func TestETagStrongWithCompression(t *testing.T) {
+	render := New(Options{
+		ETag:               true,
+		Compression:        true,
+		CompressionMinSize: 1,
+	})
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = render.For(r).Text(w, http.StatusOK, "hello")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+	identity := res.Header().Get(ETag)
+
+	res = httptest.NewRecorder()
+	req.Header.Set(AcceptEncoding, "gzip")
+	h.ServeHTTP(res, req)
+	gzipped := res.Header().Get(ETag)
+
+	expect(t, gzipped, strings.TrimSuffix(identity, `"`)+`-gzip"`)
+}
+
+func TestETagSkipped(t *testing.T) {
+	render := New(Options{
+		ETag: true,
+	})
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = render.For(r).Data(w, http.StatusCreated, []byte("hello"))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(IfNoneMatch, "*")
+	h.ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusCreated)
+	expect(t, res.Header().Get(ETag), "")
+	expect(t, res.Body.String(), "hello")
+
+	h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ETag, `"v1"`)
+		_ = render.For(r).Data(w, http.StatusOK, []byte("hello"))
+	})
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodPost, "/foo", nil)
+	req.Header.Set(IfNoneMatch, `"v1"`)
+	h.ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Header().Get(ETag), `"v1"`)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodHead, "/foo", nil)
+	req.Header.Set(IfNoneMatch, `W/"v1"`)
+	h.ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusNotModified)
+}
+
+func TestETagErrorWithoutErrorRendering(t *testing.T) {
+	render := New(Options{
+		ETag:                      true,
+		Compression:               true,
+		DisableHTTPErrorRendering: true,
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err = render.For(r).JSON(w, http.StatusOK, math.NaN()); err != nil {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(AcceptEncoding, "gzip")
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusServiceUnavailable)
+	expect(t, res.Header().Get(ETag), "")
+	expect(t, res.Header().Get(ContentEncoding), "")
+	expect(t, res.Body.String(), "unavailable\n")
+}
@@ -0,0 +1,121 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// csvGreeting is a minimal custom engine used to exercise the format registry.
+type csvGreeting struct {
+	Head
+	Separator string
+}
+
+func (c csvGreeting) Render(w io.Writer, v interface{}) error {
+	g, ok := v.(Greeting)
+	if !ok {
+		return fmt.Errorf("unsupported value %T", v)
+	}
+
+	if hw, ok := w.(http.ResponseWriter); ok {
+		c.Head.Write(hw)
+	}
+
+	_, _ = w.Write([]byte(g.One + c.Separator + g.Two))
+
+	return nil
+}
+
+func TestFormatOptions(t *testing.T) {
+	render := New(Options{
+		Formats: map[string]Format{
+			"text/csv": {
+				Engine: func(head Head) Engine {
+					return csvGreeting{Head: head, Separator: ";"}
+				},
+			},
+		},
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Format(w, "text/csv", 299, Greeting{"hello", "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, 299)
+	expect(t, res.Header().Get(ContentType), "text/csv; charset=UTF-8")
+	expect(t, res.Body.String(), "hello;world")
+}
+
+func TestFormatRegister(t *testing.T) {
+	render := New()
+	render.Register("Text/CSV; charset=utf-8", Format{
+		ContentType:    "application/vnd.greeting+csv",
+		DisableCharset: true,
+		Engine: func(head Head) Engine {
+			return csvGreeting{Head: head, Separator: ","}
+		},
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Negotiate(w, r, http.StatusOK, Greeting{"hello", "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(Accept, "text/csv, application/json;q=0.9")
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Header().Get(ContentType), "application/vnd.greeting+csv")
+	expect(t, res.Body.String(), "hello,world")
+}
+
+func TestFormatBuiltIn(t *testing.T) {
+	render := New(Options{
+		XMLContentType: "application/xml",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Format(w, ContentXML, http.StatusOK, GreetingXML{One: "hello", Two: "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), "application/xml; charset=UTF-8")
+	expect(t, res.Body.String(), "<greeting one=\"hello\" two=\"world\"></greeting>")
+}
+
+func TestFormatUnknown(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Format(w, "application/toml", http.StatusOK, Greeting{"hello", "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+}
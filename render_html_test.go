@@ -501,3 +501,93 @@ func TestHTMLTemplateOptionError(t *testing.T) {
 	expectNotNil(t, err)
 	expect(t, strings.Contains(err.Error(), "map has no entry for key"), true)
 }
+
+func TestHTMLString(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+		Layout:    "layout",
+	})
+
+	out, err := render.HTMLString("content", "gophers")
+	expectNil(t, err)
+	expect(t, out, "head\n<h1>gophers</h1>\n\nfoot\n")
+
+	out, err = render.HTMLString("hello", "<gophers>", HTMLOptions{Layout: "another_layout"})
+	expectNil(t, err)
+	expect(t, strings.Contains(out, "<h1>Hello &lt;gophers&gt;</h1>"), true)
+}
+
+func TestHTMLBytesBad(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+	})
+
+	out, err := render.HTMLBytes("nope", nil)
+	expectNotNil(t, err)
+	expect(t, len(out), 0)
+}
+
+func TestHTMLNestedLayouts(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/nested",
+		Layout:    "admin",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.HTML(w, http.StatusOK, "dashboard", "gophers")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "<html>Dashboard|<nav>dashboard</nav><h1>gophers</h1></html>")
+
+	out, err := render.HTMLString("dashboard", "gophers", HTMLOptions{Layout: "base"})
+	expectNil(t, err)
+	expect(t, out, "<html>Dashboard|<h1>gophers</h1></html>")
+}
+
+func TestHTMLNestedLayoutsBad(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/nested",
+	})
+
+	_, err := render.HTMLString("dashboard", nil, HTMLOptions{Layout: "loop-a"})
+	expect(t, err.Error(), `render: layout "loop-b" extends "loop-a", which already wraps it`)
+
+	_, err = render.HTMLString("dashboard", nil, HTMLOptions{Layout: "orphan"})
+	expect(t, err.Error(), `render: layout "orphan" extends undefined layout "missing"`)
+
+	var renderErr error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renderErr = render.HTML(w, http.StatusOK, "dashboard", nil, HTMLOptions{Layout: "orphan"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, renderErr)
+	expect(t, res.Code, http.StatusInternalServerError)
+}
+
+func TestHTMLLayoutSections(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/sections",
+		Layout:    "layout",
+	})
+
+	out, err := render.HTMLString("home", "gophers")
+	expectNil(t, err)
+	expect(t, out, `<head><script src="/home.js"></script></head><aside><b>gophers</b></aside><main>Home</main><footer>default footer</footer>`)
+
+	out, err = render.HTMLString("about", "gophers")
+	expectNil(t, err)
+	expect(t, out, `<head></head><aside>&lt;i&gt;none&lt;/i&gt;</aside><main>About</main><footer>default footer</footer>`)
+}
@@ -0,0 +1,77 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgPackBasic(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.MsgPack(w, 299, Greeting{"hello", "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, 299)
+	expect(t, res.Header().Get(ContentType), ContentMsgPack)
+
+	var out map[string]string
+	expectNil(t, msgpack.Unmarshal(res.Body.Bytes(), &out))
+	expect(t, out["one"], "hello")
+	expect(t, out["two"], "world")
+}
+
+func TestMsgPackCustomContentType(t *testing.T) {
+	render := New(Options{
+		MsgPackContentType: "application/vnd.msgpack",
+	})
+
+	res := httptest.NewRecorder()
+
+	err := render.MsgPack(res, http.StatusOK, []int{1, 2, 3})
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), "application/vnd.msgpack")
+
+	var out []int
+	expectNil(t, msgpack.Unmarshal(res.Body.Bytes(), &out))
+	expect(t, len(out), 3)
+}
+
+func TestMsgPackError(t *testing.T) {
+	render := New()
+
+	res := httptest.NewRecorder()
+
+	err := render.MsgPack(res, http.StatusOK, make(chan int))
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+}
+
+func TestNegotiateMsgPack(t *testing.T) {
+	render := New()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(Accept, "application/x-msgpack")
+
+	err := render.Negotiate(res, req, http.StatusOK, Greeting{"hello", "world"})
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), ContentMsgPack)
+
+	decoder := msgpack.NewDecoder(res.Body)
+	decoder.SetCustomStructTag("json")
+
+	var out Greeting
+	expectNil(t, decoder.Decode(&out))
+	expect(t, out, Greeting{"hello", "world"})
+}
@@ -0,0 +1,196 @@
+package render
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNDJSONSlice(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.NDJSON(w, 299, []Greeting{{"hello", "world"}, {"<b>", "&"}})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, 299)
+	expect(t, res.Flushed, true)
+	expect(t, res.Header().Get(ContentType), ContentNDJSON+"; charset=UTF-8")
+	expect(t, res.Body.String(), "{\"one\":\"hello\",\"two\":\"world\"}\n{\"one\":\"\\u003cb\\u003e\",\"two\":\"\\u0026\"}\n")
+}
+
+func TestNDJSONChannel(t *testing.T) {
+	render := New(Options{
+		UnEscapeHTML: true,
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		items := make(chan Greeting)
+
+		go func() {
+			defer close(items)
+
+			items <- Greeting{"hello", "world"}
+			items <- Greeting{"<b>", "&"}
+		}()
+
+		err = render.NDJSON(w, http.StatusOK, items)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "{\"one\":\"hello\",\"two\":\"world\"}\n{\"one\":\"<b>\",\"two\":\"&\"}\n")
+}
+
+func TestNDJSONIterator(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := 0
+		err = render.NDJSON(w, http.StatusOK, Iterator(func() (interface{}, error) {
+			if i == 3 {
+				return nil, io.EOF
+			}
+			i++
+
+			return i, nil
+		}))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Body.String(), "1\n2\n3\n")
+}
+
+func TestNDJSONEmpty(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.NDJSON(w, http.StatusOK, []Greeting{})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Header().Get(ContentType), ContentNDJSON+"; charset=UTF-8")
+	expect(t, res.Body.String(), "")
+}
+
+func TestNDJSONWithError(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.NDJSON(w, 299, []float64{math.NaN()})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+}
+
+func TestNDJSONIteratorError(t *testing.T) {
+	render := New(Options{
+		DisableHTTPErrorRendering: true,
+	})
+
+	errIterator := errors.New("iterator failed")
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent := false
+		err = render.NDJSON(w, http.StatusOK, func() (interface{}, error) {
+			if sent {
+				return nil, errIterator
+			}
+			sent = true
+
+			return "first", nil
+		})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expect(t, err, errIterator)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "\"first\"\n")
+}
+
+func TestNDJSONErrorAfterOutput(t *testing.T) {
+	render := New()
+
+	errQuery := errors.New("db failed")
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := 0
+		err = render.NDJSON(w, http.StatusOK, Iterator(func() (interface{}, error) {
+			if i == 2 {
+				return nil, errQuery
+			}
+			i++
+
+			return i, nil
+		}))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expect(t, err, errQuery)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "1\n2\n")
+}
+
+func TestNDJSONUnsupportedType(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.NDJSON(w, http.StatusOK, Greeting{"hello", "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, err.Error(), "ndjson: unsupported type render.Greeting")
+	expect(t, res.Code, http.StatusInternalServerError)
+}
@@ -0,0 +1,118 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateDefaultsToJSON(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Negotiate(w, r, http.StatusOK, Greeting{"hello", "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Header().Get(ContentType), ContentJSON+"; charset=UTF-8")
+	expect(t, res.Header().Get("Vary"), Accept)
+	expect(t, res.Body.String(), "{\"one\":\"hello\",\"two\":\"world\"}")
+}
+
+func TestNegotiateQualityValues(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Negotiate(w, r, 299, GreetingXML{One: "hello", Two: "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(Accept, "application/json;q=0.5, application/xml, */*;q=0.1")
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, 299)
+	expect(t, res.Header().Get(ContentType), ContentXML+"; charset=UTF-8")
+	expect(t, res.Body.String(), "<greeting one=\"hello\" two=\"world\"></greeting>")
+}
+
+func TestNegotiateHTMLTemplate(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Negotiate(w, r, http.StatusOK, HTMLTemplate{Name: "hello", Binding: "gophers"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(Accept, "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), ContentHTML+"; charset=UTF-8")
+	expect(t, res.Body.String(), "<h1>Hello gophers</h1>\n")
+
+	res = httptest.NewRecorder()
+	req.Header.Set(Accept, "application/*")
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), ContentJSON+"; charset=UTF-8")
+	expect(t, res.Body.String(), "\"gophers\"")
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Negotiate(w, r, http.StatusOK, []byte("hello"))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(Accept, "application/json, text/*;q=0")
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusNotAcceptable)
+	expect(t, res.Header().Get("Vary"), Accept)
+}
+
+func TestNegotiateSpecificity(t *testing.T) {
+	offers := []string{ContentJSON, ContentText, ContentHTML}
+
+	expect(t, negotiate("", offers), 0)
+	expect(t, negotiate("text/*", offers), 1)
+	expect(t, negotiate("text/*;q=0.5, text/html", offers), 2)
+	expect(t, negotiate("text/html;q=0, */*", offers), 0)
+	expect(t, negotiate("*/*;q=0.1, text/plain;charset=utf-8", offers), 1)
+	expect(t, negotiate("text/html;level=1", offers), -1)
+	expect(t, negotiate("image/png", offers), -1)
+}
+
+func TestAddVary(t *testing.T) {
+	h := http.Header{}
+	h.Set("Vary", "Origin, accept")
+
+	addVary(h, Accept)
+	addVary(h, "Accept-Encoding")
+
+	expect(t, len(h.Values("Vary")), 2)
+	expect(t, h.Values("Vary")[1], "Accept-Encoding")
+}
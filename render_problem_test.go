@@ -0,0 +1,110 @@
+package render
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemJSON(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Problem(w, http.StatusForbidden, Problem{
+			Type:     "https://example.com/probs/out-of-credit",
+			Title:    "You do not have enough credit.",
+			Detail:   "Your current balance is 30, but that costs 50.",
+			Instance: "/account/12345/msgs/abc",
+			Extensions: map[string]interface{}{
+				"balance":  30,
+				"accounts": []string{"/account/12345", "/account/67890"},
+				"title":    "ignored",
+			},
+		})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusForbidden)
+	expect(t, res.Header().Get(ContentType), ContentProblemJSON+"; charset=UTF-8")
+	expect(t, res.Body.String(), `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","status":403,`+
+		`"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc",`+
+		`"accounts":["/account/12345","/account/67890"],"balance":30}`)
+}
+
+func TestProblemJSONUnEscapeHTML(t *testing.T) {
+	render := New(Options{
+		UnEscapeHTML: true,
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Problem(w, http.StatusBadRequest, Problem{Status: 422, Detail: "<b>", Extensions: map[string]interface{}{"field": "&"}})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusBadRequest)
+	expect(t, res.Body.String(), `{"status":422,"detail":"<b>","field":"&"}`)
+}
+
+func TestProblemXML(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.ProblemXML(w, http.StatusNotFound, Problem{
+			Title:      "Not Found",
+			Extensions: map[string]interface{}{"ids": []int{1, 2}},
+		})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusNotFound)
+	expect(t, res.Header().Get(ContentType), ContentProblemXML+"; charset=UTF-8")
+	expect(t, res.Body.String(), `<problem xmlns="urn:ietf:rfc:7807"><title>Not Found</title><status>404</status><ids><i>1</i><i>2</i></ids></problem>`)
+}
+
+func TestProblemErrors(t *testing.T) {
+	render := New(Options{
+		ProblemErrors: true,
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Render(w, errorEngine{}, nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Header().Get(ContentType), ContentProblemJSON+"; charset=UTF-8")
+	expect(t, res.Body.String(), `{"title":"Internal Server Error","status":500,"detail":"engine failed"}`)
+}
+
+// errorEngine is an engine that always fails.
+type errorEngine struct{}
+
+func (errorEngine) Render(_ io.Writer, _ interface{}) error {
+	return errors.New("engine failed")
+}
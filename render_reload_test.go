@@ -0,0 +1,104 @@
+package render
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTemplateVersion(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+	})
+
+	expect(t, render.TemplateVersion(), uint64(1))
+
+	expectNil(t, render.CompileTemplates())
+	expect(t, render.TemplateVersion(), uint64(2))
+}
+
+func TestOnReload(t *testing.T) {
+	dir := t.TempDir()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "hello.tmpl"), []byte("Hello {{ . }}"), 0o600))
+
+	render := New(Options{
+		Directory: dir,
+	})
+
+	var events []ReloadEvent
+
+	unsubscribe := render.OnReload(func(event ReloadEvent) {
+		events = append(events, event)
+	})
+
+	expectNil(t, render.CompileTemplates())
+	expect(t, len(events), 1)
+	expect(t, events[0].Version, uint64(2))
+	expect(t, len(events[0].Files), 0)
+	expectNil(t, events[0].Err)
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "hello.tmpl"), []byte("Hello {{ . "), 0o600))
+
+	err := render.CompileTemplates()
+	expectNotNil(t, err)
+	expect(t, len(events), 2)
+	expect(t, events[1].Version, uint64(2))
+	expect(t, errors.Is(events[1].Err, err), true)
+
+	unsubscribe()
+	unsubscribe()
+
+	_ = render.CompileTemplates()
+	expect(t, len(events), 2)
+}
+
+func TestOnReloadWatcher(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "hello.tmpl")
+
+	expectNil(t, os.WriteFile(file, []byte("Hello {{ . }}"), 0o600))
+
+	for name, interval := range map[string]time.Duration{"fsnotify": 0, "polling": 10 * time.Millisecond} {
+		t.Run(name, func(t *testing.T) {
+			render := New(Options{
+				Directory:      dir,
+				IsDevelopment:  true,
+				ReloadInterval: interval,
+			})
+			defer render.Close()
+
+			var (
+				lock   sync.Mutex
+				events []ReloadEvent
+			)
+
+			render.OnReload(func(event ReloadEvent) {
+				lock.Lock()
+				defer lock.Unlock()
+
+				events = append(events, event)
+			})
+
+			expectNil(t, os.WriteFile(file, []byte("Goodbye "+name), 0o600))
+
+			eventually(t, func() bool {
+				lock.Lock()
+				defer lock.Unlock()
+
+				return len(events) > 0
+			})
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			expect(t, events[0].Version > 1, true)
+			expect(t, len(events[0].Files), 1)
+			expect(t, filepath.Base(events[0].Files[0]), "hello.tmpl")
+			expectNil(t, events[0].Err)
+		})
+	}
+}
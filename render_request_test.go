@@ -0,0 +1,206 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type contextKey struct{}
+
+// requestEngine writes a value from the request's context.
+type requestEngine struct {
+	Head
+}
+
+func (e requestEngine) Render(w io.Writer, v interface{}) error {
+	return e.RenderRequest(w, nil, v)
+}
+
+func (e requestEngine) RenderRequest(w io.Writer, req *http.Request, v interface{}) error {
+	value := "none"
+	if req != nil {
+		value, _ = req.Context().Value(contextKey{}).(string)
+	}
+
+	if hw, ok := w.(http.ResponseWriter); ok {
+		e.Head.Write(hw)
+	}
+
+	_, err := io.WriteString(w, value)
+
+	return err
+}
+
+func TestRequestHTML(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.For(r).HTML(w, http.StatusOK, "request", nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo?name=gophers", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Header().Get(ContentType), ContentHTML+"; charset=UTF-8")
+	expect(t, res.Body.String(), "<h1>Hello gophers</h1>\n")
+}
+
+func TestRequestHTMLWithoutRequest(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.HTML(w, http.StatusOK, "request", nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo?name=gophers", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+}
+
+func TestRequestHTMLDoesNotLeak(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo?name=gophers", nil)
+	out, err := render.For(req).HTMLString("request", nil)
+
+	expectNil(t, err)
+	expect(t, out, "<h1>Hello gophers</h1>\n")
+
+	out, err = render.HTMLString("request", nil)
+
+	expectNotNil(t, err)
+	expect(t, strings.Contains(out, "gophers"), false)
+}
+
+func TestRequestHTMLReusesTemplates(t *testing.T) {
+	dir := t.TempDir()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "layout.tmpl"), []byte("<main>{{ yield }}</main>"), 0o600))
+	expectNil(t, os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte("{{ greet }}"), 0o600))
+
+	render := New(Options{
+		Directory: dir,
+		Funcs:     []template.FuncMap{{"greet": func() string { return "hello" }}},
+	})
+
+	out, err := render.HTMLString("page", nil, HTMLOptions{
+		Layout: "layout",
+		Funcs:  template.FuncMap{"greet": func() string { return "bye" }},
+	})
+
+	expectNil(t, err)
+	expect(t, out, "<main>bye</main>")
+
+	// The functions of the previous render are not kept by the templates it used.
+	for i := 0; i < 3; i++ {
+		out, err = render.HTMLString("page", nil)
+
+		expectNil(t, err)
+		expect(t, out, "hello")
+
+		_, err = render.HTMLString("layout", nil)
+
+		expectNotNil(t, err)
+	}
+}
+
+func TestRequestHTMLConcurrent(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+	})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("gopher%d", i)
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo?name="+name, nil)
+			out, err := render.For(req).HTMLString("request", nil)
+
+			expectNil(t, err)
+			expect(t, out, "<h1>Hello "+name+"</h1>\n")
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func TestRequestEngine(t *testing.T) {
+	render := New()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := requestEngine{Head{ContentType: ContentText, Status: http.StatusOK}}
+
+		if r.URL.Query().Get("for") == "true" {
+			_ = render.For(r).Render(w, e, nil)
+		} else {
+			_ = render.Render(w, e, nil)
+		}
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(context.WithValue(ctx, contextKey{}, "from context"), http.MethodGet, "/foo?for=true", nil)
+	h.ServeHTTP(res, req)
+
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "from context")
+
+	res = httptest.NewRecorder()
+	req.URL.RawQuery = ""
+	h.ServeHTTP(res, req)
+
+	expect(t, res.Body.String(), "none")
+}
+
+func TestRequestMatchesRender(t *testing.T) {
+	render := New()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("for") == "true" {
+			_ = render.For(r).JSON(w, http.StatusCreated, Greeting{"hello", "world"})
+		} else {
+			_ = render.JSON(w, http.StatusCreated, Greeting{"hello", "world"})
+		}
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	forRes := httptest.NewRecorder()
+	req.URL.RawQuery = "for=true"
+	h.ServeHTTP(forRes, req)
+
+	expect(t, forRes.Code, res.Code)
+	expect(t, forRes.Header().Get(ContentType), res.Header().Get(ContentType))
+	expect(t, forRes.Body.String(), res.Body.String())
+}
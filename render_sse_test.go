@@ -0,0 +1,110 @@
+package render
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventStreamBasic(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events := make(chan Event, 3)
+		events <- Event{ID: "1", Event: "greeting", Data: Greeting{"hello", "<world>"}}
+		events <- Event{Retry: 2 * time.Second, Data: "line one\nline two"}
+		events <- Event{ID: "bad\nid", Data: []byte("raw")}
+		close(events)
+
+		err = render.EventStream(w, r, events)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Flushed, true)
+	expect(t, res.Header().Get(ContentType), ContentEventStream+"; charset=UTF-8")
+	expect(t, res.Header().Get("Cache-Control"), "no-cache")
+	expect(t, res.Body.String(), "id: 1\nevent: greeting\ndata: {\"one\":\"hello\",\"two\":\"\\u003cworld\\u003e\"}\n\n"+
+		"retry: 2000\ndata: line one\ndata: line two\n\n"+
+		"id: badid\ndata: raw\n\n")
+}
+
+func TestEventStreamUnEscapeHTML(t *testing.T) {
+	render := New(Options{
+		UnEscapeHTML: true,
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events := make(chan Event, 1)
+		events <- Event{Data: Greeting{"hello", "<world>"}}
+		close(events)
+
+		err = render.EventStream(w, r, events)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Body.String(), "data: {\"one\":\"hello\",\"two\":\"<world>\"}\n\n")
+}
+
+func TestEventStreamContextCanceled(t *testing.T) {
+	render := New(Options{
+		EventStreamHeartbeat: time.Millisecond,
+	})
+
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		err = render.EventStream(w, r, make(chan Event))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, strings.HasPrefix(res.Body.String(), ": heartbeat\n\n"), true)
+}
+
+func TestEventStreamEncodeError(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events := make(chan Event, 2)
+		events <- Event{Data: "first"}
+		events <- Event{Data: make(chan int)}
+		close(events)
+
+		err = render.EventStream(w, r, events)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, err.Error(), "json: unsupported type: chan int")
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Body.String(), "data: first\n\n")
+}
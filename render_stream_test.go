@@ -0,0 +1,225 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newStreamingRender(t *testing.T, res *httptest.ResponseRecorder, seen *string) *Render {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, contents := range map[string]string{
+		"layout.tmpl": "<head></head>{{ yield }}",
+		"page.tmpl":   "{{ seen }}<p>{{ . }}</p>",
+		"broken.tmpl": "{{ fail }}",
+		"late.tmpl":   "<p>{{ . }}</p>{{ fail }}",
+		"early.tmpl":  "<p>early</p>{{ flush }}{{ seen }}<p>{{ . }}</p>",
+	} {
+		expectNil(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600))
+	}
+
+	return New(Options{
+		Directory:     dir,
+		StreamingHTML: true,
+		Compression:   true,
+		ETag:          true,
+		Funcs: []template.FuncMap{{
+			"seen": func() string {
+				*seen = res.Body.String()
+				if !res.Flushed {
+					*seen = "not flushed"
+				}
+
+				return ""
+			},
+			"fail": func() (string, error) {
+				return "", errors.New("failed")
+			},
+		}},
+	})
+}
+
+func TestStreamingHTML(t *testing.T) {
+	var seen string
+
+	res := httptest.NewRecorder()
+	render := newStreamingRender(t, res, &seen)
+
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set(AcceptEncoding, "gzip")
+
+	err := render.For(req).HTML(res, http.StatusCreated, "page", "gophers", HTMLOptions{Layout: "layout"})
+	expectNil(t, err)
+	expect(t, seen, "<head></head>")
+	expect(t, res.Code, http.StatusCreated)
+	expect(t, res.Header().Get(ContentType), ContentHTML+"; charset=UTF-8")
+	expect(t, res.Header().Get(ContentEncoding), "")
+	expect(t, res.Header().Get(ETag), "")
+	expect(t, res.Body.String(), "<head></head><p>gophers</p>")
+}
+
+func TestStreamingHTMLErrorBeforeOutput(t *testing.T) {
+	var seen string
+
+	res := httptest.NewRecorder()
+	render := New(Options{
+		Directory:     "testdata/basic",
+		StreamingHTML: true,
+	})
+
+	err := render.HTML(res, http.StatusOK, "missing", nil)
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+
+	res = httptest.NewRecorder()
+	render = newStreamingRender(t, res, &seen)
+
+	err = render.HTML(res, http.StatusOK, "broken", nil)
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+	expect(t, res.Header().Get(ContentType), "text/plain; charset=utf-8")
+}
+
+func TestStreamingHTMLErrorAfterOutput(t *testing.T) {
+	var seen string
+
+	res := httptest.NewRecorder()
+	render := newStreamingRender(t, res, &seen)
+
+	err := render.HTML(res, http.StatusOK, "late", "gophers")
+	expectNotNil(t, err)
+	expect(t, errors.As(err, &streamError{}), false)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Header().Get(ContentType), ContentHTML+"; charset=UTF-8")
+	expect(t, res.Body.String(), "<p>gophers</p>")
+}
+
+func TestStreamingHTMLFlush(t *testing.T) {
+	var seen string
+
+	res := httptest.NewRecorder()
+	render := newStreamingRender(t, res, &seen)
+
+	err := render.HTML(res, http.StatusOK, "early", "gophers")
+	expectNil(t, err)
+	expect(t, seen, "<p>early</p>")
+	expect(t, res.Body.String(), "<p>early</p><p>gophers</p>")
+
+	res = httptest.NewRecorder()
+	render = newStreamingRender(t, res, &seen)
+
+	err = render.HTML(res, http.StatusOK, "early", "gophers", HTMLOptions{Layout: "layout"})
+	expectNil(t, err)
+	expect(t, seen, "<head></head><p>early</p>")
+	expect(t, res.Body.String(), "<head></head><p>early</p><p>gophers</p>")
+}
+
+func TestFlushWithoutStreaming(t *testing.T) {
+	dir := t.TempDir()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "early.tmpl"), []byte("<p>early</p>{{ flush }}<p>{{ . }}</p>"), 0o600))
+
+	render := New(Options{
+		Directory: dir,
+	})
+
+	res := httptest.NewRecorder()
+
+	err := render.HTML(res, http.StatusOK, "early", "gophers")
+	expectNil(t, err)
+	expect(t, res.Flushed, false)
+	expect(t, res.Body.String(), "<p>early</p><p>gophers</p>")
+}
+
+// streamConcurrently renders the page template for many requests at once, and checks that each
+// response was flushed before the template rendered, and holds only its own output. The renders
+// wait for each other before they yield or flush, so all of them are in progress at once.
+func streamConcurrently(t *testing.T, layout string) {
+	t.Helper()
+
+	const renders = 50
+
+	dir := t.TempDir()
+
+	for name, contents := range map[string]string{
+		"layout.tmpl": "{{ wait }}<head></head>{{ yield }}",
+		"page.tmpl":   "{{ wait }}<p>{{ .Name }}</p>{{ flush }}{{ flushed .Res }}",
+	} {
+		expectNil(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600))
+	}
+
+	var waiting int32
+
+	ready := make(chan struct{})
+
+	render := New(Options{
+		Directory:     dir,
+		Layout:        layout,
+		StreamingHTML: true,
+		Funcs: []template.FuncMap{{
+			"wait": func() string {
+				if atomic.AddInt32(&waiting, 1) == renders {
+					close(ready)
+				}
+
+				select {
+				case <-ready:
+				case <-time.After(5 * time.Second):
+				}
+
+				return ""
+			},
+			"flushed": func(res *httptest.ResponseRecorder) string {
+				return fmt.Sprint(res.Flushed)
+			},
+		}},
+	})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < renders; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+			binding := struct {
+				Name string
+				Res  *httptest.ResponseRecorder
+			}{fmt.Sprintf("gopher%d", i), res}
+
+			err := render.For(req).HTML(res, http.StatusOK, "page", binding)
+			expectNil(t, err)
+
+			want := fmt.Sprintf("<p>gopher%d</p>true", i)
+			if len(layout) > 0 {
+				want = "<head></head>" + want
+			}
+
+			expect(t, res.Body.String(), want)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func TestStreamingHTMLConcurrent(t *testing.T) {
+	streamConcurrently(t, "layout")
+}
+
+func TestStreamingHTMLFlushConcurrent(t *testing.T) {
+	streamConcurrently(t, "")
+}
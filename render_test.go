@@ -41,6 +41,64 @@ func TestLockConfig(t *testing.T) {
 	expect(t, reflect.TypeOf(r4.lock).Kind(), empty)
 }
 
+func TestErrorHandler(t *testing.T) {
+	var (
+		handledErr    error
+		handledEngine Engine
+	)
+
+	render := New(Options{
+		Directory: "testdata/basic",
+		ErrorHandler: func(w http.ResponseWriter, err error, e Engine) {
+			handledErr, handledEngine = err, e
+
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("Something went wrong."))
+		},
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.HTML(w, http.StatusOK, "nope", nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, handledErr, err)
+	expect(t, handledEngine.(HTML).Name, "nope")
+	expect(t, res.Code, http.StatusServiceUnavailable)
+	expect(t, res.Body.String(), "Something went wrong.")
+}
+
+func TestErrorHandlerDisabled(t *testing.T) {
+	called := false
+
+	render := New(Options{
+		DisableHTTPErrorRendering: true,
+		ErrorHandler: func(w http.ResponseWriter, err error, e Engine) {
+			called = true
+		},
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Render(w, errorEngine{}, nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, called, false)
+	expect(t, res.Body.String(), "")
+}
+
 // Benchmarks.
 func BenchmarkNormalJSON(b *testing.B) {
 	render := New()
@@ -92,6 +150,25 @@ func BenchmarkHTML(b *testing.B) {
 	})
 }
 
+func BenchmarkHTMLLayout(b *testing.B) {
+	render := New(Options{
+		Directory: "testdata/basic",
+		Layout:    "layout",
+	})
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = render.HTML(w, http.StatusOK, "content", "gophers")
+	})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			h.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	})
+}
+
 // Test Helpers.
 func expect(t *testing.T, a interface{}, b interface{}) {
 	t.Helper()
@@ -0,0 +1,189 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTextTemplateBasic(t *testing.T) {
+	render := New(Options{
+		TextDirectory: "testdata/text",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.TextTemplate(w, http.StatusOK, "email", "<gopher>")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, http.StatusOK)
+	expect(t, res.Header().Get(ContentType), ContentText+"; charset=UTF-8")
+	expect(t, res.Body.String(), "<gopher> says <hi> & \"bye\".\n")
+}
+
+func TestTextTemplateLayout(t *testing.T) {
+	render := New(Options{
+		TextDirectory: "testdata/text",
+		TextLayout:    "layout",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.TextTemplate(w, http.StatusOK, "email", "gophers")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Body.String(), "Dear gophers,\ngophers says <hi> & \"bye\".\nReply to unsubscribe.\n-- email\n")
+}
+
+func TestTextTemplateLayoutOverride(t *testing.T) {
+	render := New(Options{
+		TextDirectory: "testdata/text",
+		TextLayout:    "layout",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.TextTemplate(w, http.StatusOK, "hello", "gophers", HTMLOptions{Layout: "hello"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Body.String(), "Hello gophers!\n")
+}
+
+func TestTextTemplateSuppliedContentType(t *testing.T) {
+	render := New(Options{
+		TextDirectory: "testdata/text",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ContentType, "text/csv")
+		err = render.TextTemplate(w, http.StatusOK, "list", []string{"alice", "bob"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), "text/csv")
+	expect(t, res.Body.String(), "name,greeting\nalice,hello\nbob,hello\n")
+}
+
+func TestTextTemplateDefaultDirectory(t *testing.T) {
+	render := New(Options{
+		Directory:      "testdata/basic",
+		TextExtensions: []string{".tmpl"},
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.TextTemplate(w, http.StatusOK, "hello", "<gophers>")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Body.String(), "<h1>Hello <gophers></h1>\n")
+	expectNotNil(t, render.TextTemplateLookup("hello"))
+}
+
+func TestTextTemplateOptIn(t *testing.T) {
+	dir := t.TempDir()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "hello.tmpl"), []byte("Hello {{ . }}"), 0o600))
+	expectNil(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("{{ not a template"), 0o600))
+
+	render := New(Options{
+		Directory: dir,
+	})
+
+	out, err := render.HTMLString("hello", "gophers")
+
+	expectNil(t, err)
+	expect(t, out, "Hello gophers")
+	expect(t, render.TextTemplateLookup("notes") == nil, true)
+}
+
+func TestTextTemplateMissing(t *testing.T) {
+	render := New(Options{
+		TextDirectory: "testdata/text",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.TextTemplate(w, http.StatusOK, "nope", nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+}
+
+func TestTextTemplateString(t *testing.T) {
+	render := New(Options{
+		TextDirectory: "testdata/text",
+		TextLayout:    "layout",
+	})
+
+	out, err := render.TextTemplateString("email", "gophers")
+	expectNil(t, err)
+	expect(t, out, "Dear gophers,\ngophers says <hi> & \"bye\".\nReply to unsubscribe.\n-- email\n")
+
+	b, err := render.TextTemplateBytes("nope", nil)
+	expectNotNil(t, err)
+	expect(t, len(b), 0)
+}
+
+func TestTextTemplateNestedLayouts(t *testing.T) {
+	render := New(Options{
+		TextDirectory: "testdata/text",
+		TextLayout:    "section",
+	})
+
+	out, err := render.TextTemplateString("email", "gophers")
+	expectNil(t, err)
+	expect(t, out, "Dear gophers,\nSection: gophers says <hi> & \"bye\".\nReply to unsubscribe.\n-- email\n")
+}
+
+func TestTextTemplateLayoutSections(t *testing.T) {
+	render := New(Options{
+		TextDirectory: "testdata/text",
+		TextLayout:    "sections",
+	})
+
+	out, err := render.TextTemplateString("named", "<gophers>")
+	expectNil(t, err)
+	expect(t, out, "Hello <gophers>")
+
+	out, err = render.TextTemplateString("hello", "gophers")
+	expectNil(t, err)
+	expect(t, out, "Hi Hello gophers!\n")
+}
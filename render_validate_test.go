@@ -0,0 +1,72 @@
+package render
+
+import (
+	"errors"
+	"html/template"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+		Layout:    "layout",
+	})
+
+	expectNil(t, render.Validate())
+}
+
+func TestValidateErrors(t *testing.T) {
+	render := New(Options{
+		Directory: "testdata/basic",
+	})
+
+	render.opt.Directory = "testdata/invalid"
+	render.opt.TextDirectory = "testdata/invalid"
+	render.opt.Layout = "layout"
+	render.opt.RequirePartials = true
+	render.opt.Funcs = []template.FuncMap{{"upper": func(string) string { return "" }}}
+
+	err := render.Validate()
+
+	var errs ValidationErrors
+
+	expect(t, errors.As(err, &errs), true)
+	expect(t, len(errs), 6)
+	expect(t, errs[0], ValidationError{Template: "about", Message: `partial "css-about" not defined`})
+	expect(t, errs[1], ValidationError{Template: "about", Line: 2, Message: `function "frobnicate" not defined`})
+	expect(t, errs[2], ValidationError{Template: "broken", Message: `partial "css-broken" not defined`})
+	expect(t, errs[3], ValidationError{Template: "broken", Line: 1, Message: "missing value for if"})
+	expect(t, errs[4], ValidationError{Template: "layout", Line: 3, Message: `template "missing" not defined`})
+	expect(t, errs[5], ValidationError{Template: "orphan", Message: `layout "orphan" extends undefined layout "nope"`})
+	expect(t, errs[1].Error(), `about:2: function "frobnicate" not defined`)
+}
+
+func TestValidateMissingLayout(t *testing.T) {
+	render := New(Options{
+		Directory:  "testdata/basic",
+		Layout:     "nope",
+		TextLayout: "nope",
+	})
+
+	err := render.Validate()
+
+	expect(t, err.Error(), "render: invalid templates:\nnope: layout not defined\nnope: layout not defined")
+}
+
+func TestValidateRedefinedTemplate(t *testing.T) {
+	dir := t.TempDir()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "a.tmpl"), []byte(`{{ define "title" }}A{{ end }}<h1>{{ template "title" }}</h1>`), 0o600))
+	expectNil(t, os.WriteFile(filepath.Join(dir, "b.tmpl"), []byte(`{{ define "title" }}B{{ end }}<h2>{{ template "title" }}</h2>`), 0o600))
+
+	render, err := NewWithError(Options{
+		Directory: dir,
+	})
+	expectNil(t, err)
+
+	_, err = render.HTMLString("a", nil)
+	expectNil(t, err)
+	expectNil(t, render.Validate())
+}
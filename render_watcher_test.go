@@ -0,0 +1,127 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// eventually waits for the condition to become true.
+func eventually(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return
+		}
+	}
+
+	t.Fatal("condition not met before the deadline")
+}
+
+func TestWatcherReload(t *testing.T) {
+	dir := t.TempDir()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "hello.tmpl"), []byte("Hello {{ . }}"), 0o600))
+
+	render := New(Options{
+		Directory:     dir,
+		IsDevelopment: true,
+	})
+	defer render.Close()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "hello.tmpl"), []byte("Goodbye {{ . }}"), 0o600))
+
+	eventually(t, func() bool {
+		out, _ := render.HTMLString("hello", "gophers")
+
+		return out == "Goodbye gophers"
+	})
+
+	expectNil(t, os.MkdirAll(filepath.Join(dir, "admin", "users"), 0o700))
+	expectNil(t, os.WriteFile(filepath.Join(dir, "admin", "users", "index.tmpl"), []byte("Users"), 0o600))
+
+	eventually(t, func() bool {
+		return render.TemplateLookup("admin/users/index") != nil
+	})
+}
+
+func TestWatcherClose(t *testing.T) {
+	dir := t.TempDir()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "hello.tmpl"), []byte("Hello {{ . }}"), 0o600))
+
+	render := New(Options{
+		Directory:     dir,
+		IsDevelopment: true,
+	})
+
+	expectNil(t, render.Close())
+	expectNil(t, render.Close())
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "hello.tmpl"), []byte("Goodbye {{ . }}"), 0o600))
+	time.Sleep(3 * reloadDebounce)
+
+	out, err := render.HTMLString("hello", "gophers")
+	expectNil(t, err)
+	expect(t, out, "Hello gophers")
+}
+
+func TestCloseWithoutWatcher(t *testing.T) {
+	render := New()
+
+	expectNil(t, render.Close())
+}
+
+func TestIgnoredFile(t *testing.T) {
+	for name, ignored := range map[string]bool{
+		"templates/home.tmpl":           false,
+		"templates/admin":               false,
+		"templates/.home.tmpl.swp":      true,
+		"templates/home.tmpl.swx":       true,
+		"templates/home.tmpl~":          true,
+		"templates/#home.tmpl#":         true,
+		"templates/.#home.tmpl":         true,
+		"templates/4913":                true,
+		"templates/home.tmpl.tmp":       true,
+		"templates/admin/edit.tmpl.bak": true,
+	} {
+		expect(t, ignoredFile(name), ignored)
+	}
+}
+
+func TestWatcherPolling(t *testing.T) {
+	dir := t.TempDir()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "hello.tmpl"), []byte("Hello {{ . }}"), 0o600))
+
+	render := New(Options{
+		Directory:      ".",
+		TextDirectory:  ".",
+		FileSystem:     FS(os.DirFS(dir)),
+		IsDevelopment:  true,
+		ReloadInterval: 10 * time.Millisecond,
+	})
+	defer render.Close()
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "hello.tmpl"), []byte("Goodbye {{ . }}"), 0o600))
+
+	eventually(t, func() bool {
+		out, _ := render.HTMLString("hello", "gophers")
+
+		return out == "Goodbye gophers"
+	})
+
+	expectNil(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("New {{ . }}"), 0o600))
+
+	eventually(t, func() bool {
+		return render.TextTemplateLookup("new") != nil
+	})
+}
+
+func TestLocalTemplates(t *testing.T) {
+	expect(t, New(Options{Directory: "testdata/basic"}).localTemplates(), true)
+	expect(t, New(Options{Directory: "testdata/basic", FileSystem: LocalFileSystem{}}).localTemplates(), true)
+	expect(t, New(Options{Directory: ".", FileSystem: FS(os.DirFS("testdata/basic"))}).localTemplates(), false)
+}
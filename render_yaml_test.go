@@ -0,0 +1,111 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type GreetingYAML struct {
+	One   string   `yaml:"one"`
+	Two   string   `yaml:"two"`
+	Items []string `yaml:"items,omitempty"`
+}
+
+func TestYAMLBasic(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.YAML(w, 299, GreetingYAML{One: "hello", Two: "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Code, 299)
+	expect(t, res.Header().Get(ContentType), ContentYAML+"; charset=UTF-8")
+	expect(t, res.Body.String(), "{one: hello, two: world}\n")
+}
+
+func TestYAMLPrefixAndIndent(t *testing.T) {
+	render := New(Options{
+		PrefixYAML:     []byte("---\n"),
+		IndentYAML:     true,
+		DisableCharset: true,
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.YAML(w, http.StatusOK, map[string]GreetingYAML{"greeting": {One: "hello", Two: "world", Items: []string{"a"}}})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), ContentYAML)
+	expect(t, res.Body.String(), "---\ngreeting:\n  one: hello\n  two: world\n  items:\n    - a\n")
+}
+
+func TestYAMLFlowStyle(t *testing.T) {
+	render := New(Options{
+		PrefixYAML: []byte("---\n"),
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.YAML(w, http.StatusOK, map[string]GreetingYAML{"greeting": {One: "hello", Two: "a, b", Items: []string{"a", "b"}}})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Body.String(), "---\n{greeting: {one: hello, two: 'a, b', items: [a, b]}}\n")
+}
+
+func TestYAMLCustomContentType(t *testing.T) {
+	render := New(Options{
+		YAMLContentType: "text/x-yaml",
+	})
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.Negotiate(w, r, http.StatusOK, GreetingYAML{One: "hello", Two: "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	req.Header.Set(Accept, "application/yaml")
+	h.ServeHTTP(res, req)
+
+	expectNil(t, err)
+	expect(t, res.Header().Get(ContentType), "text/x-yaml; charset=UTF-8")
+	expect(t, res.Body.String(), "{one: hello, two: world}\n")
+}
+
+func TestYAMLWithError(t *testing.T) {
+	render := New()
+
+	var err error
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = render.YAML(w, 299, make(chan int))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	h.ServeHTTP(res, req)
+
+	expectNotNil(t, err)
+	expect(t, res.Code, http.StatusInternalServerError)
+}
@@ -0,0 +1,695 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	texttemplate "text/template"
+)
+
+// RequestEngine is implemented by engines that make use of the request being rendered,
+// such as reading its context. RenderRequest is called instead of Render whenever the
+// engine is rendered through a RequestRender.
+type RequestEngine interface {
+	Engine
+	RenderRequest(w io.Writer, req *http.Request, v interface{}) error
+}
+
+// RequestRender renders responses to a single request. It provides the features that
+// need to know about the request, such as response compression and conditional requests,
+// and passes the request on to RequestEngines and templates. Create one with Render.For.
+type RequestRender struct {
+	r   *Render
+	req *http.Request
+}
+
+// For returns a RequestRender that renders responses to the given request. With a nil
+// request, it renders exactly like the Render functions of the same name.
+func (r *Render) For(req *http.Request) *RequestRender {
+	return &RequestRender{r: r, req: req}
+}
+
+// Request returns the request being rendered.
+func (rr *RequestRender) Request() *http.Request {
+	return rr.req
+}
+
+// Render renders the data with the given engine, which receives the request if it is a RequestEngine.
+func (rr *RequestRender) Render(w io.Writer, e Engine, data interface{}) error {
+	return rr.r.render(w, rr.req, e, data)
+}
+
+// CBOR marshals the given interface object and writes the CBOR response.
+func (rr *RequestRender) CBOR(w io.Writer, status int, v interface{}) error {
+	head := Head{
+		ContentType: rr.r.opt.CBORContentType,
+		Status:      status,
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, rr.r.newCBOR(head), v)
+	})
+}
+
+// CSV writes the rows of v as comma separated values. See the CSV engine for the values
+// that can be rendered.
+func (rr *RequestRender) CSV(w io.Writer, status int, v interface{}, csvOpt ...CSVOptions) error {
+	return rr.csv(w, rr.r.opt.CSVContentType, ',', status, v, csvOpt)
+}
+
+// csv writes the rows of v separated by the delimiter, unless the options set another one.
+func (rr *RequestRender) csv(w io.Writer, contentType string, delimiter rune, status int, v interface{}, csvOpt []CSVOptions) error {
+	var opt CSVOptions
+	if len(csvOpt) > 0 {
+		opt = csvOpt[0]
+	}
+
+	if opt.Delimiter != 0 {
+		delimiter = opt.Delimiter
+	}
+
+	head := Head{
+		ContentType: contentType + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	c := CSV{
+		Head:      head,
+		Delimiter: delimiter,
+		BOM:       opt.BOM,
+		Filename:  opt.Filename,
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, c, v)
+	})
+}
+
+// Data writes out the raw bytes as binary data.
+func (rr *RequestRender) Data(w io.Writer, status int, v []byte) error {
+	head := Head{
+		ContentType: rr.r.opt.BinaryContentType,
+		Status:      status,
+	}
+
+	d := Data{
+		Head: head,
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, d, v)
+	})
+}
+
+// EventStream sends the events as Server-Sent Events until the channel is closed or the
+// request's context is done. Event data is JSON encoded with the same options as JSON.
+func (rr *RequestRender) EventStream(w http.ResponseWriter, events <-chan Event) error {
+	head := Head{
+		ContentType: ContentEventStream + rr.r.compiledCharset,
+		Status:      http.StatusOK,
+	}
+
+	s := SSE{
+		Head:         head,
+		Heartbeat:    rr.r.opt.EventStreamHeartbeat,
+		Indent:       rr.r.opt.IndentJSON,
+		UnEscapeHTML: rr.r.opt.UnEscapeHTML,
+	}
+
+	return rr.Render(w, s, events)
+}
+
+// Format renders v with the format registered for the media type.
+func (rr *RequestRender) Format(w io.Writer, mediaType string, status int, v interface{}) error {
+	format, ok := rr.r.lookupFormat(mediaType)
+	if !ok {
+		return rr.failed(w, fmt.Errorf("render: no format registered for %q", mediaType))
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, rr.r.formatEngine(format, status), v)
+	})
+}
+
+// HTML builds up the response from the specified template and bindings.
+// Templates can call the "request" function to access the request being rendered.
+func (rr *RequestRender) HTML(w io.Writer, status int, name string, binding interface{}, htmlOpt ...HTMLOptions) error {
+	var flush *flushPoint
+	if rr.r.opt.StreamingHTML {
+		flush = &flushPoint{}
+	}
+
+	templates, name, release, err := rr.prepareHTML(name, binding, flush, htmlOpt)
+	if err != nil {
+		return rr.failed(w, err)
+	}
+	defer release()
+
+	head := Head{
+		ContentType: rr.r.opt.HTMLContentType + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	h := HTML{
+		Head:          head,
+		Name:          name,
+		Templates:     templates,
+		StreamingHTML: rr.r.opt.StreamingHTML,
+		bp:            rr.r.opt.BufferPool,
+		flush:         flush,
+	}
+
+	if h.StreamingHTML {
+		return rr.Render(w, h, binding)
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, h, binding)
+	})
+}
+
+// HTMLBytes executes the specified template and bindings like HTML, and returns the result
+// instead of writing a response.
+func (rr *RequestRender) HTMLBytes(name string, binding interface{}, htmlOpt ...HTMLOptions) ([]byte, error) {
+	templates, name, release, err := rr.prepareHTML(name, binding, nil, htmlOpt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx := requestContext(rr.req)
+
+	var buf bytes.Buffer
+
+	err = templates.ExecuteTemplate(withContext(ctx, &buf), name, binding)
+	if ctxErr := contextErr(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// HTMLString executes the specified template and bindings like HTML, and returns the result
+// instead of writing a response.
+func (rr *RequestRender) HTMLString(name string, binding interface{}, htmlOpt ...HTMLOptions) (string, error) {
+	b, err := rr.HTMLBytes(name, binding, htmlOpt...)
+
+	return string(b), err
+}
+
+// prepareHTML installs the layout and request functions for the named template on a clone of
+// the templates, and returns the clone along with the name of the template to execute, which
+// is the outermost layout. The layouts flush the response at the flush point when it is streamed.
+// The clone is reused by other renders once release is called.
+func (rr *RequestRender) prepareHTML(name string, binding interface{}, flush *flushPoint, htmlOpt []HTMLOptions) (*template.Template, string, func(), error) {
+	r := rr.r
+
+	sets, err := r.compiledTemplates()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// The functions below are bound to this render, so they are set on a clone no other
+	// render uses at the same time.
+	templates, release, err := sets.htmlClone()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	opt := r.prepareHTMLOptions(r.opt.Layout, htmlOpt)
+	if tpl := templates.Lookup(name); tpl != nil {
+		if len(opt.Layout) > 0 {
+			defined := func(n string) bool { return templates.Lookup(n) != nil }
+
+			l, err := r.newLayout(templates, defined, sets.htmlParents, opt.Layout, name, binding)
+			if err != nil {
+				release()
+
+				return nil, "", nil, err
+			}
+
+			l.ctx = requestContext(rr.req)
+			l.flush = flush
+			tpl.Funcs(r.layoutFuncs(l))
+			name = l.outermost()
+		}
+
+		tpl.Funcs(flushFuncs(flush))
+		tpl.Funcs(rr.requestFuncs())
+
+		if len(opt.Funcs) > 0 {
+			tpl.Funcs(opt.Funcs)
+		}
+	}
+
+	return templates, name, release, nil
+}
+
+// JSON marshals the given interface object and writes the JSON response.
+// Streaming JSON responses are written straight through without compression or an ETag.
+func (rr *RequestRender) JSON(w io.Writer, status int, v interface{}) error {
+	head := Head{
+		ContentType: rr.r.opt.JSONContentType + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	if rr.r.opt.StreamingJSON {
+		return rr.Render(w, rr.r.newJSON(head), v)
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, rr.r.newJSON(head), v)
+	})
+}
+
+// JSONP marshals the given interface object and writes the JSON response.
+func (rr *RequestRender) JSONP(w io.Writer, status int, callback string, v interface{}) error {
+	head := Head{
+		ContentType: rr.r.opt.JSONPContentType + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	j := JSONP{
+		Head:     head,
+		Indent:   rr.r.opt.IndentJSON,
+		Callback: callback,
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, j, v)
+	})
+}
+
+// Negotiate renders v with the engine that best matches the request's Accept header.
+// See Render.Negotiate for the representations that are offered.
+func (rr *RequestRender) Negotiate(w http.ResponseWriter, status int, v interface{}) error {
+	offers := rr.offers(v)
+
+	mediaTypes := make([]string, 0, len(offers))
+	for _, o := range offers {
+		mediaTypes = append(mediaTypes, o.mediaType)
+	}
+
+	addVary(w.Header(), Accept)
+
+	accept := ""
+	if rr.req != nil {
+		accept = strings.Join(rr.req.Header.Values(Accept), ",")
+	}
+
+	i := negotiate(accept, mediaTypes)
+	if i < 0 {
+		return rr.Text(w, http.StatusNotAcceptable, http.StatusText(http.StatusNotAcceptable))
+	}
+
+	return offers[i].render(w, status)
+}
+
+func (rr *RequestRender) offers(v interface{}) []offer {
+	var offers []offer
+
+	switch t := v.(type) {
+	case HTMLTemplate:
+		offers = append(offers, offer{rr.r.opt.HTMLContentType, func(w io.Writer, status int) error {
+			return rr.HTML(w, status, t.Name, t.Binding, t.HTMLOptions)
+		}})
+		v = t.Binding
+	case string:
+		offers = append(offers, offer{rr.r.opt.TextContentType, func(w io.Writer, status int) error {
+			return rr.Text(w, status, t)
+		}})
+	case []byte:
+		// Raw bytes have no meaningful representation in any other format.
+		return []offer{{rr.r.opt.BinaryContentType, func(w io.Writer, status int) error {
+			return rr.Data(w, status, t)
+		}}}
+	}
+
+	return append(offers, rr.formatOffers(v)...)
+}
+
+// MsgPack marshals the given interface object and writes the MessagePack response.
+func (rr *RequestRender) MsgPack(w io.Writer, status int, v interface{}) error {
+	head := Head{
+		ContentType: rr.r.opt.MsgPackContentType,
+		Status:      status,
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, rr.r.newMsgPack(head), v)
+	})
+}
+
+// NDJSON writes each item of the given channel, slice, array or Iterator as a line of JSON,
+// flushing the response after every item.
+func (rr *RequestRender) NDJSON(w io.Writer, status int, v interface{}) error {
+	head := Head{
+		ContentType: rr.r.opt.NDJSONContentType + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	n := NDJSON{
+		Head:         head,
+		UnEscapeHTML: rr.r.opt.UnEscapeHTML,
+	}
+
+	return rr.Render(w, n, v)
+}
+
+// Problem writes the problem details as an application/problem+json response.
+func (rr *RequestRender) Problem(w io.Writer, status int, p Problem) error {
+	head := Head{
+		ContentType: ContentProblemJSON + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	if p.Status == 0 {
+		p.Status = status
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, rr.r.newJSON(head), p)
+	})
+}
+
+// ProblemXML writes the problem details as an application/problem+xml response.
+func (rr *RequestRender) ProblemXML(w io.Writer, status int, p Problem) error {
+	head := Head{
+		ContentType: ContentProblemXML + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	if p.Status == 0 {
+		p.Status = status
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, rr.r.newXML(head), p)
+	})
+}
+
+// Text writes out a string as plain text.
+func (rr *RequestRender) Text(w io.Writer, status int, v string) error {
+	head := Head{
+		ContentType: rr.r.opt.TextContentType + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	t := Text{
+		Head: head,
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, t, v)
+	})
+}
+
+// TSV writes the rows of v as tab separated values. See the CSV engine for the values
+// that can be rendered.
+func (rr *RequestRender) TSV(w io.Writer, status int, v interface{}, csvOpt ...CSVOptions) error {
+	return rr.csv(w, rr.r.opt.TSVContentType, '\t', status, v, csvOpt)
+}
+
+// TextTemplate builds up the response from the specified text template and bindings. Text
+// templates are not escaped, and a Content-Type header set beforehand is kept.
+func (rr *RequestRender) TextTemplate(w io.Writer, status int, name string, binding interface{}, htmlOpt ...HTMLOptions) error {
+	templates, name, release, err := rr.prepareTextTemplate(name, binding, htmlOpt)
+	if err != nil {
+		return rr.failed(w, err)
+	}
+	defer release()
+
+	head := Head{
+		ContentType: rr.r.opt.TextContentType + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	t := TextTemplate{
+		Head:      head,
+		Name:      name,
+		Templates: templates,
+		bp:        rr.r.opt.BufferPool,
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, t, binding)
+	})
+}
+
+// TextTemplateBytes executes the specified text template and bindings like TextTemplate, and
+// returns the result instead of writing a response.
+func (rr *RequestRender) TextTemplateBytes(name string, binding interface{}, htmlOpt ...HTMLOptions) ([]byte, error) {
+	templates, name, release, err := rr.prepareTextTemplate(name, binding, htmlOpt)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx := requestContext(rr.req)
+
+	var buf bytes.Buffer
+
+	err = templates.ExecuteTemplate(withContext(ctx, &buf), name, binding)
+	if ctxErr := contextErr(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// TextTemplateString executes the specified text template and bindings like TextTemplate, and
+// returns the result instead of writing a response.
+func (rr *RequestRender) TextTemplateString(name string, binding interface{}, htmlOpt ...HTMLOptions) (string, error) {
+	b, err := rr.TextTemplateBytes(name, binding, htmlOpt...)
+
+	return string(b), err
+}
+
+// prepareTextTemplate is prepareHTML for text templates.
+func (rr *RequestRender) prepareTextTemplate(name string, binding interface{}, htmlOpt []HTMLOptions) (*texttemplate.Template, string, func(), error) {
+	r := rr.r
+
+	sets, err := r.compiledTemplates()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	templates, release, err := sets.textClone()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	opt := r.prepareHTMLOptions(r.opt.TextLayout, htmlOpt)
+	if tpl := templates.Lookup(name); tpl != nil {
+		if len(opt.Layout) > 0 {
+			defined := func(n string) bool { return templates.Lookup(n) != nil }
+
+			l, err := r.newLayout(templates, defined, sets.textParents, opt.Layout, name, binding)
+			if err != nil {
+				release()
+
+				return nil, "", nil, err
+			}
+
+			l.ctx = requestContext(rr.req)
+			tpl.Funcs(r.textLayoutFuncs(l))
+			name = l.outermost()
+		}
+
+		tpl.Funcs(texttemplate.FuncMap(rr.requestFuncs()))
+
+		if len(opt.Funcs) > 0 {
+			tpl.Funcs(texttemplate.FuncMap(opt.Funcs))
+		}
+	}
+
+	return templates, name, release, nil
+}
+
+// XML marshals the given interface object and writes the XML response.
+func (rr *RequestRender) XML(w io.Writer, status int, v interface{}) error {
+	head := Head{
+		ContentType: rr.r.opt.XMLContentType + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, rr.r.newXML(head), v)
+	})
+}
+
+// YAML marshals the given interface object and writes the YAML response.
+func (rr *RequestRender) YAML(w io.Writer, status int, v interface{}) error {
+	head := Head{
+		ContentType: rr.r.opt.YAMLContentType + rr.r.compiledCharset,
+		Status:      status,
+	}
+
+	return rr.buffered(w, func(w io.Writer) error {
+		return rr.Render(w, rr.r.newYAML(head), v)
+	})
+}
+
+// failed renders an error that occurred before rendering began, and returns it.
+// Compile errors are rendered as the development error page when it is enabled.
+func (rr *RequestRender) failed(w io.Writer, err error) error {
+	hw, ok := w.(http.ResponseWriter)
+
+	var compileErr *CompileError
+
+	switch {
+	case !ok || isContextError(err):
+	case errors.As(err, &compileErr) && rr.r.opt.IsDevelopment && rr.r.opt.DevErrorPage:
+		page := compileErrorPage{
+			Head: Head{ContentType: ContentHTML + rr.r.compiledCharset, Status: http.StatusInternalServerError},
+		}
+
+		_ = page.Render(hw, compileErr)
+	case !rr.r.opt.DisableHTTPErrorRendering:
+		rr.r.renderError(hw, err, nil)
+	}
+
+	return err
+}
+
+// requestFuncs are the template functions that expose the request being rendered, which is
+// nil without one.
+func (rr *RequestRender) requestFuncs() template.FuncMap {
+	return template.FuncMap{
+		"request": func() *http.Request {
+			return rr.req
+		},
+	}
+}
+
+// buffered captures the complete response written by render, so it can be post-processed
+// before anything is sent to the client.
+func (rr *RequestRender) buffered(w io.Writer, render func(w io.Writer) error) error {
+	hw, ok := w.(http.ResponseWriter)
+	if !ok || rr.req == nil || (!rr.r.opt.Compression && !rr.r.opt.ETag) {
+		return render(w)
+	}
+
+	buf := rr.r.opt.BufferPool.Get()
+	defer rr.r.opt.BufferPool.Put(buf)
+
+	br := &bufferedResponse{ResponseWriter: hw, buf: buf}
+	err := render(br)
+
+	// Nothing is sent once the request's context is done.
+	if err == nil {
+		err = rr.req.Context().Err()
+	}
+
+	if isContextError(err) {
+		return err
+	}
+
+	// The handler can still respond to an error that occurred before anything was written.
+	if err != nil && br.status == 0 && br.buf.Len() == 0 {
+		return err
+	}
+
+	if writeErr := rr.writeBuffered(br); err == nil {
+		err = writeErr
+	}
+
+	return err
+}
+
+// writeBuffered sends the captured response, adding an ETag and compressing it when possible.
+func (rr *RequestRender) writeBuffered(br *bufferedResponse) error {
+	status := br.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	h := br.Header()
+
+	var c *Compressor
+
+	if rr.r.opt.Compression {
+		addVary(h, AcceptEncoding)
+
+		if br.buf.Len() >= rr.r.opt.CompressionMinSize && compressible(status, h) {
+			c = rr.r.compressor(strings.Join(rr.req.Header.Values(AcceptEncoding), ","))
+		}
+	}
+
+	if rr.r.opt.ETag && status == http.StatusOK {
+		// An ETag set by the handler is kept, but still used to answer conditional requests.
+		etag := h.Get(ETag)
+		if len(etag) == 0 {
+			encoding := ""
+			if c != nil {
+				encoding = c.Encoding
+			}
+
+			etag = computeETag(br.buf.Bytes(), rr.r.opt.WeakETag, encoding)
+			h.Set(ETag, etag)
+		}
+
+		method := rr.req.Method
+		if match := strings.Join(rr.req.Header.Values(IfNoneMatch), ","); (method == http.MethodGet || method == http.MethodHead) && len(match) > 0 && etagMatches(match, etag) {
+			h.Del(ContentType)
+			h.Del(ContentLength)
+			br.ResponseWriter.WriteHeader(http.StatusNotModified)
+
+			return nil
+		}
+	}
+
+	if c != nil {
+		h.Set(ContentEncoding, c.Encoding)
+		h.Del(ContentLength)
+		br.ResponseWriter.WriteHeader(status)
+
+		cw := c.NewWriter(withContext(rr.req.Context(), br.ResponseWriter))
+		if _, err := br.buf.WriteTo(cw); err != nil {
+			_ = cw.Close()
+
+			return err
+		}
+
+		return cw.Close()
+	}
+
+	br.ResponseWriter.WriteHeader(status)
+	_, err := br.buf.WriteTo(withContext(rr.req.Context(), br.ResponseWriter))
+
+	return err
+}
+
+// bufferedResponse is a http.ResponseWriter that holds the status and body in memory.
+// It deliberately does not implement http.Flusher.
+type bufferedResponse struct {
+	http.ResponseWriter
+	buf    *bytes.Buffer
+	status int
+}
+
+func (br *bufferedResponse) WriteHeader(status int) {
+	if br.status == 0 {
+		br.status = status
+	}
+}
+
+func (br *bufferedResponse) Write(p []byte) (int, error) {
+	if br.status == 0 {
+		br.status = http.StatusOK
+	}
+
+	return br.buf.Write(p)
+}
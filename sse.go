@@ -0,0 +1,169 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a single message sent by the SSE engine.
+type Event struct {
+	// ID sets the client's last event ID.
+	ID string
+	// Event names the event type. Clients dispatch unnamed events as "message".
+	Event string
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+	// Data is sent as is when it is a string or byte slice, and is JSON encoded otherwise.
+	Data interface{}
+}
+
+// SSE built-in renderer.
+type SSE struct {
+	Head
+	// Context stops the stream once it is done. Defaults to never stopping.
+	Context context.Context //nolint:containedctx
+	// Heartbeat is the interval between comments sent to keep idle connections open. Disabled if zero.
+	Heartbeat    time.Duration
+	Indent       bool
+	UnEscapeHTML bool
+}
+
+// Render a stream of events from a <-chan Event. Each event is flushed as soon as it is
+// written, and the stream ends when the channel is closed or the Context is done.
+func (s SSE) Render(w io.Writer, v interface{}) error {
+	var events <-chan Event
+
+	switch e := v.(type) {
+	case <-chan Event:
+		events = e
+	case chan Event:
+		events = e
+	default:
+		return fmt.Errorf("sse: unsupported type %T", v)
+	}
+
+	done := context.Background().Done()
+	if s.Context != nil {
+		done = s.Context.Done()
+	}
+
+	var heartbeat <-chan time.Time
+
+	if s.Heartbeat > 0 {
+		ticker := time.NewTicker(s.Heartbeat)
+		defer ticker.Stop()
+
+		heartbeat = ticker.C
+	}
+
+	if hw, ok := w.(http.ResponseWriter); ok {
+		hw.Header().Set("Cache-Control", "no-cache")
+		hw.Header().Set("Connection", "keep-alive")
+		hw.Header().Set("X-Accel-Buffering", "no")
+		s.Head.Write(hw)
+	}
+
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	flush()
+
+	var buf bytes.Buffer
+
+	for {
+		buf.Reset()
+
+		select {
+		case <-done:
+			return nil
+		case <-heartbeat:
+			buf.WriteString(": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			// The stream started already, so errors can not be rendered anymore.
+			if err := s.encode(&buf, event); err != nil {
+				return streamError{err}
+			}
+		}
+
+		if _, err := buf.WriteTo(w); err != nil {
+			return streamError{err}
+		}
+
+		flush()
+	}
+}
+
+// RenderRequest renders the stream until the request's context is done, unless a Context is set.
+func (s SSE) RenderRequest(w io.Writer, req *http.Request, v interface{}) error {
+	if s.Context == nil {
+		s.Context = req.Context()
+	}
+
+	return s.Render(w, v)
+}
+
+// encode writes the event in the text/event-stream format.
+func (s SSE) encode(buf *bytes.Buffer, event Event) error {
+	var data string
+
+	switch d := event.Data.(type) {
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		var out bytes.Buffer
+
+		encoder := json.NewEncoder(&out)
+		encoder.SetEscapeHTML(!s.UnEscapeHTML)
+
+		if s.Indent {
+			encoder.SetIndent("", "  ")
+		}
+
+		if err := encoder.Encode(d); err != nil {
+			return err
+		}
+
+		data = strings.TrimSuffix(out.String(), "\n")
+	}
+
+	// Line breaks would end the field early, so they are dropped from single line fields.
+	singleLine := strings.NewReplacer("\r", "", "\n", "")
+
+	if len(event.ID) > 0 {
+		buf.WriteString("id: " + singleLine.Replace(event.ID) + "\n")
+	}
+
+	if len(event.Event) > 0 {
+		buf.WriteString("event: " + singleLine.Replace(event.Event) + "\n")
+	}
+
+	if event.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	data = strings.ReplaceAll(strings.ReplaceAll(data, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+
+	buf.WriteString("\n")
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// streamWriter writes a streaming response straight to the client. The header is written
+// along with the first bytes, so errors before any output can still be rendered as usual.
+type streamWriter struct {
+	w       io.Writer
+	head    Head
+	started bool
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	sw.writeHead()
+
+	return sw.w.Write(p)
+}
+
+func (sw *streamWriter) writeHead() {
+	if sw.started {
+		return
+	}
+
+	sw.started = true
+
+	if hw, ok := sw.w.(http.ResponseWriter); ok {
+		sw.head.Write(hw)
+	}
+}
+
+// Flush sends everything written so far to the client.
+func (sw *streamWriter) Flush() {
+	sw.writeHead()
+
+	if flusher, ok := sw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// flushPoint lets the layout functions flush the streaming response they render into.
+// It is created before the response is, and is a no-op unless the response is streamed.
+type flushPoint struct {
+	sw *streamWriter
+}
+
+func (f *flushPoint) flush() {
+	if f != nil && f.sw != nil {
+		f.sw.Flush()
+	}
+}
+
+// writer returns the streaming response, or nil unless the response is streamed.
+func (f *flushPoint) writer() io.Writer {
+	if f == nil || f.sw == nil {
+		return nil
+	}
+
+	return f.sw
+}
+
+// flushFuncs are the template functions that flush the response at the flush point.
+func flushFuncs(flush *flushPoint) template.FuncMap {
+	return template.FuncMap{
+		"flush": func() string {
+			flush.flush()
+
+			return ""
+		},
+	}
+}
+
+// streamError is an error that occurred after a streaming response was started. The status
+// has been sent already, so it is returned without rendering an error response.
+type streamError struct {
+	err error
+}
+
+func (e streamError) Error() string {
+	return e.err.Error()
+}
+
+func (e streamError) Unwrap() error {
+	return e.err
+}
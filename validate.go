@@ -0,0 +1,324 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// ValidationError is a problem found in a template by Validate.
+type ValidationError struct {
+	// Template is the name of the template file, or the layout that is missing.
+	Template string
+	// Line is the line of the template file the problem was found on, or 0 if unknown.
+	Line int
+	// Message describes the problem.
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.Template, e.Line, e.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Template, e.Message)
+}
+
+// ValidationErrors lists every problem found by Validate.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+
+	return "render: invalid templates:\n" + strings.Join(lines, "\n")
+}
+
+// builtinFuncs are the functions predefined by text/template and html/template.
+var builtinFuncs = []string{
+	"and", "call", "html", "index", "slice", "js", "len", "not", "or", "print", "printf", "println", "urlquery",
+	"eq", "ge", "gt", "le", "lt", "ne",
+}
+
+// Validate reads the HTML and text templates and reports, as ValidationErrors:
+//   - syntax errors and calls to undefined functions,
+//   - references to undefined templates,
+//   - undefined layouts, whether set by Options.Layout, Options.TextLayout or extended by another layout,
+//   - with RequirePartials, templates missing a partial used by their layouts.
+//
+// Only the layouts set in Options are checked for partials, as the ones set by HTMLOptions
+// are only known at render time. Validate returns nil if all templates are valid.
+func (r *Render) Validate() error {
+	funcs := map[string]bool{}
+
+	for _, name := range builtinFuncs {
+		funcs[name] = true
+	}
+
+	for name := range helperFuncs() {
+		funcs[name] = true
+	}
+
+	// Only defined by layoutFuncs, as it is deprecated.
+	funcs["block"] = true
+
+	for _, fm := range r.opt.Funcs {
+		for name := range fm {
+			funcs[name] = true
+		}
+	}
+
+	var errs ValidationErrors
+
+	errs = append(errs, r.validateTemplates(r.opt.Directory, r.opt.Extensions, r.opt.Layout, funcs)...)
+	errs = append(errs, r.validateTemplates(r.opt.TextDirectory, r.opt.TextExtensions, r.opt.TextLayout, funcs)...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// validateTemplates validates the templates with the given extensions in dir.
+func (r *Render) validateTemplates(dir string, extensions []string, layoutName string, funcs map[string]bool) ValidationErrors {
+	var (
+		errs  ValidationErrors
+		files []string
+	)
+
+	trees := map[string]*parse.Tree{}
+
+	err := r.readTemplates(dir, extensions, func(_, name string, buf []byte) error {
+		files = append(files, name)
+
+		t := parse.New(name)
+		t.Mode = parse.SkipFuncCheck
+
+		fileTrees := map[string]*parse.Tree{}
+		if _, err := t.Parse(string(buf), r.opt.Delims.Left, r.opt.Delims.Right, fileTrees); err != nil {
+			errs = append(errs, parseError(name, err))
+
+			return nil
+		}
+
+		// Like the template packages, later definitions replace earlier ones, unless they are empty.
+		for treeName, tree := range fileTrees {
+			if old, ok := trees[treeName]; ok && !parse.IsEmptyTree(old.Root) && parse.IsEmptyTree(tree.Root) {
+				continue
+			}
+
+			trees[treeName] = tree
+		}
+
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, ValidationError{Template: dir, Message: err.Error()})
+	}
+
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	defined := func(name string) bool {
+		_, ok := trees[name]
+
+		return ok
+	}
+
+	parents := map[string]string{}
+
+	for _, name := range names {
+		tree := trees[name]
+
+		if parent := extends(tree); len(parent) > 0 {
+			parents[name] = parent
+		}
+
+		walkNodes(tree.Root, func(node parse.Node) {
+			switch n := node.(type) {
+			case *parse.IdentifierNode:
+				if !funcs[n.Ident] {
+					errs = append(errs, nodeError(tree, n, fmt.Sprintf("function %q not defined", n.Ident)))
+				}
+			case *parse.TemplateNode:
+				if !defined(n.Name) {
+					errs = append(errs, nodeError(tree, n, fmt.Sprintf("template %q not defined", n.Name)))
+				}
+			}
+		})
+	}
+
+	for _, name := range names {
+		if _, err := layoutChain(defined, parents, name); err != nil {
+			errs = append(errs, ValidationError{Template: trees[name].ParseName, Message: strings.TrimPrefix(err.Error(), "render: ")})
+		}
+	}
+
+	if len(layoutName) > 0 {
+		if !defined(layoutName) {
+			errs = append(errs, ValidationError{Template: layoutName, Message: "layout not defined"})
+		} else if chain, err := layoutChain(defined, parents, layoutName); err == nil && r.opt.RequirePartials {
+			errs = append(errs, r.validatePartials(trees, files, parents, chain)...)
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Template != errs[j].Template {
+			return errs[i].Template < errs[j].Template
+		}
+
+		return errs[i].Line < errs[j].Line
+	})
+
+	return errs
+}
+
+// validatePartials checks that every template file, other than layouts and partials, defines
+// the partials used by the layouts.
+func (r *Render) validatePartials(trees map[string]*parse.Tree, files []string, parents map[string]string, layouts []string) ValidationErrors {
+	var (
+		errs     ValidationErrors
+		partials []string
+	)
+
+	isLayout := map[string]bool{}
+
+	for _, l := range layouts {
+		isLayout[l] = true
+
+		walkNodes(trees[l].Root, func(node parse.Node) {
+			cmd, ok := node.(*parse.CommandNode)
+			if !ok || len(cmd.Args) != 2 {
+				return
+			}
+
+			if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "partial" {
+				if partial, ok := cmd.Args[1].(*parse.StringNode); ok {
+					partials = append(partials, partial.Text)
+				}
+			}
+		})
+	}
+
+	for _, file := range files {
+		if _, extends := parents[file]; extends || isLayout[file] || isPartial(file, partials) {
+			continue
+		}
+
+		for _, partial := range partials {
+			if _, ok := trees[partial+"-"+file]; ok {
+				continue
+			}
+
+			if _, ok := trees[partial]; ok && r.opt.RenderPartialsWithoutPrefix {
+				continue
+			}
+
+			errs = append(errs, ValidationError{Template: file, Message: fmt.Sprintf("partial %q not defined", partial+"-"+file)})
+		}
+	}
+
+	return errs
+}
+
+// isPartial reports whether the template file defines one of the partials for another template.
+func isPartial(file string, partials []string) bool {
+	for _, partial := range partials {
+		if strings.HasPrefix(file, partial+"-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readTemplates calls add with the path, name and contents of every template in dir with one of the extensions.
+func (r *Render) readTemplates(dir string, extensions []string, add func(path, name string, buf []byte) error) error {
+	if r.opt.Asset == nil || r.opt.AssetNames == nil {
+		return r.walkDir(dir, extensions, add)
+	}
+
+	return r.walkAssets(dir, extensions, add)
+}
+
+// parseError converts a parse error of the form "template: name:line: message".
+func parseError(name string, err error) ValidationError {
+	msg := strings.TrimPrefix(err.Error(), "template: ")
+
+	if rest := strings.TrimPrefix(msg, name+":"); rest != msg {
+		if lineStr, message, ok := cut(rest, ": "); ok {
+			if line, err := strconv.Atoi(lineStr); err == nil {
+				return ValidationError{Template: name, Line: line, Message: message}
+			}
+		}
+	}
+
+	return ValidationError{Template: name, Message: msg}
+}
+
+// nodeError returns an error located at the node.
+func nodeError(tree *parse.Tree, node parse.Node, message string) ValidationError {
+	location, _ := tree.ErrorContext(node)
+
+	// The location has the form "name:line:column".
+	parts := strings.Split(location, ":")
+	line := 0
+
+	if len(parts) >= 3 {
+		line, _ = strconv.Atoi(parts[len(parts)-2])
+	}
+
+	return ValidationError{Template: tree.ParseName, Line: line, Message: message}
+}
+
+// walkNodes calls visit for the node and every node below it.
+func walkNodes(node parse.Node, visit func(parse.Node)) {
+	visit(node)
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			walkNodes(child, visit)
+		}
+	case *parse.ActionNode:
+		walkNodes(n.Pipe, visit)
+	case *parse.PipeNode:
+		for _, cmd := range n.Cmds {
+			walkNodes(cmd, visit)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkNodes(arg, visit)
+		}
+	case *parse.ChainNode:
+		walkNodes(n.Node, visit)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			walkNodes(n.Pipe, visit)
+		}
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, visit)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, visit)
+	}
+}
+
+func walkBranch(n *parse.BranchNode, visit func(parse.Node)) {
+	walkNodes(n.Pipe, visit)
+	walkNodes(n.List, visit)
+
+	if n.ElseList != nil {
+		walkNodes(n.ElseList, visit)
+	}
+}
@@ -0,0 +1,272 @@
+package render
+
+import (
+	"crypto/sha256"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Time to wait for a burst of changes, such as an editor saving several files, to end before reloading.
+const reloadDebounce = 100 * time.Millisecond
+
+// Interval between checks for changed templates when they are not read from a local directory.
+const defaultReloadInterval = time.Second
+
+// watcher recompiles the templates in development mode whenever they change, either by
+// watching their directories or by polling a checksum of their contents.
+type watcher struct {
+	fsw  *fsnotify.Watcher // Nil when polling.
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// watch starts reloading the templates when they change. Templates in a local directory
+// are watched with fsnotify, unless ReloadInterval is set, and any other source is polled.
+func (r *Render) watch() {
+	w := &watcher{
+		done: make(chan struct{}),
+	}
+
+	interval := r.opt.ReloadInterval
+
+	if interval <= 0 && !r.localTemplates() {
+		interval = defaultReloadInterval
+	}
+
+	var run func()
+
+	if interval > 0 {
+		last := r.checksums()
+		run = func() { w.poll(r, interval, last) }
+	} else {
+		fsw, err := fsnotify.NewWatcher()
+		if err != nil {
+			log.Printf("Unable to create new watcher for template files. Templates will be recompiled on every render. Error: %v\n", err)
+
+			return
+		}
+
+		w.fsw = fsw
+		w.add(r.opt.FileSystem, r.opt.Directory)
+
+		if r.opt.TextDirectory != r.opt.Directory {
+			w.add(r.opt.FileSystem, r.opt.TextDirectory)
+		}
+
+		run = func() { w.run(r) }
+	}
+
+	r.lock.Lock()
+	r.watcher = w
+	r.hasWatcher = true
+	r.lock.Unlock()
+
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+		run()
+	}()
+}
+
+// localTemplates reports whether the templates are read from the local file system, which fsnotify can watch.
+func (r *Render) localTemplates() bool {
+	if r.opt.Asset != nil && r.opt.AssetNames != nil {
+		return false
+	}
+
+	switch r.opt.FileSystem.(type) {
+	case LocalFileSystem, *LocalFileSystem:
+		return true
+	}
+
+	return false
+}
+
+// checksums hashes the contents of every template by path.
+func (r *Render) checksums() map[string][sha256.Size]byte {
+	sums := map[string][sha256.Size]byte{}
+
+	add := func(path, _ string, buf []byte) error {
+		sums[path] = sha256.Sum256(buf)
+
+		return nil
+	}
+
+	// Errors are hashed too, so that a file becoming unreadable or readable again is a change.
+	if err := r.readTemplates(r.opt.Directory, r.opt.Extensions, add); err != nil {
+		sums[r.opt.Directory] = sha256.Sum256([]byte(err.Error()))
+	}
+
+	if err := r.readTemplates(r.opt.TextDirectory, r.opt.TextExtensions, add); err != nil {
+		sums[r.opt.TextDirectory] = sha256.Sum256([]byte(err.Error()))
+	}
+
+	return sums
+}
+
+// changedFiles returns the paths whose checksums were added, removed or changed.
+func changedFiles(last, sums map[string][sha256.Size]byte) []string {
+	var changed []string
+
+	for path, sum := range sums {
+		if lastSum, ok := last[path]; !ok || lastSum != sum {
+			changed = append(changed, path)
+		}
+	}
+
+	for path := range last {
+		if _, ok := sums[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+// Close stops reloading the templates in development mode, and waits for the background
+// goroutine to exit. The templates in use at that point keep being used.
+func (r *Render) Close() error {
+	r.lock.RLock()
+	w := r.watcher
+	r.lock.RUnlock()
+
+	if w == nil {
+		return nil
+	}
+
+	return w.close()
+}
+
+// add watches dir and every directory below it.
+func (w *watcher) add(fs FileSystem, dir string) {
+	_ = fs.Walk(dir, func(path string, info os.FileInfo, _ error) error {
+		if info != nil && info.IsDir() {
+			_ = w.fsw.Add(path)
+		}
+
+		return nil
+	})
+}
+
+func (w *watcher) run(r *Render) {
+	var reload <-chan time.Time
+
+	changed := map[string]bool{}
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+
+			if ignoredFile(event.Name) {
+				continue
+			}
+
+			// New directories are not watched by fsnotify on their own.
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					w.add(r.opt.FileSystem, event.Name)
+				}
+			}
+
+			changed[event.Name] = true
+			reload = time.After(reloadDebounce)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+
+			log.Printf("Error watching template files: %v\n", err)
+		case <-reload:
+			files := make([]string, 0, len(changed))
+			for path := range changed {
+				files = append(files, path)
+			}
+
+			sort.Strings(files)
+
+			reload = nil
+			changed = map[string]bool{}
+
+			w.reload(r, files)
+		}
+	}
+}
+
+// poll recompiles the templates whenever their checksums change.
+func (w *watcher) poll(r *Render, interval time.Duration, last map[string][sha256.Size]byte) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			sums := r.checksums()
+
+			if files := changedFiles(last, sums); len(files) > 0 {
+				last = sums
+				w.reload(r, files)
+			}
+		}
+	}
+}
+
+// reload recompiles the templates after the files changed.
+func (w *watcher) reload(r *Render, files []string) {
+	if err := r.compileTemplates(files); err != nil {
+		log.Printf("Unable to reload templates. The last good templates are still used. Error: %v\n", err)
+	}
+}
+
+func (w *watcher) close() error {
+	var err error
+
+	w.once.Do(func() {
+		close(w.done)
+
+		if w.fsw != nil {
+			err = w.fsw.Close()
+		}
+
+		w.wg.Wait()
+	})
+
+	return err
+}
+
+// ignoredFile reports whether the file is a temporary or swap file written by an editor.
+func ignoredFile(path string) bool {
+	name := filepath.Base(path)
+
+	switch {
+	case strings.HasPrefix(name, "."), // Hidden files, such as .#lock files and vim's .swp files.
+		strings.HasPrefix(name, "#") && strings.HasSuffix(name, "#"), // Emacs auto-save files.
+		strings.HasSuffix(name, "~"),
+		name == "4913": // Written by vim to check that a directory is writable.
+		return true
+	}
+
+	switch filepath.Ext(name) {
+	case ".swp", ".swo", ".swx", ".tmp", ".bak":
+		return true
+	}
+
+	return false
+}